@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/app"
+	"invoices-api/pkg/database"
+)
+
+func runCheckConfig(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("check-config", flag.ExitOnError)
+	offline := fs.Bool("offline", false, "skip the database checks")
+	fs.Parse(args)
+
+	if err := app.CheckConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Println("Configuration is valid")
+
+	if *offline {
+		return nil
+	}
+
+	// A single attempt: a check should fail fast rather than retry.
+	db, err := database.ConnectDB(databaseConfig(cfg))
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	if err := sqlDB.PingContext(context.Background()); err != nil {
+		return fmt.Errorf("database is not reachable: %w", err)
+	}
+	fmt.Printf("Connected to %s on %s:%d\n", cfg.Database.Name, cfg.Database.Host, cfg.Database.Port)
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d migration(s) pending; run \"migrate up\"", pending)
+	}
+	fmt.Println("Database schema is up to date")
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"invoices-api/config"
+	"invoices-api/internal/cache"
+	"invoices-api/internal/export"
+	"invoices-api/internal/repository"
+	"io"
+	"os"
+	"strings"
+)
+
+func runExport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	org := fs.String("org", "default", "organization slug")
+	formatName := fs.String("format", string(export.FormatCSV), "csv or xlsx")
+	columnSpec := fs.String("columns", "", "comma separated columns (default: all)")
+	localeTag := fs.String("locale", export.DefaultLocale, "number and date format")
+	search := fs.String("search", "", "only invoices whose service name contains this")
+	output := fs.String("o", "-", "output file, - for standard output")
+	fs.Parse(args)
+
+	format, err := export.ParseFormat(strings.ToLower(*formatName))
+	if err != nil {
+		return err
+	}
+	columns, err := export.ParseColumns(*columnSpec)
+	if err != nil {
+		return err
+	}
+	locale, err := export.LookupLocale(*localeTag)
+	if err != nil {
+		return err
+	}
+
+	db := openDB(cfg)
+	ctx, _, err := organizationContext(context.Background(), db, *org)
+	if err != nil {
+		return err
+	}
+
+	var dest io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		dest = file
+	}
+
+	buffered := bufio.NewWriter(dest)
+	writer, err := export.NewWriter(format, buffered, columns, locale)
+	if err != nil {
+		return err
+	}
+
+	// Exports read straight from the database, so no cache is needed.
+	repo := repository.NewInvoiceRepository(db, cache.Nop{}, 0, false)
+	if err := repo.Export(ctx, *search, repository.QueryParams{}, writer.WriteRow); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
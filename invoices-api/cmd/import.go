@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/app"
+	"invoices-api/internal/export"
+	"invoices-api/internal/importer"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/validator"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runImport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	org := fs.String("org", "default", "organization slug")
+	formatName := fs.String("format", "", "csv or jsonl (default: from the file extension, else csv)")
+	localeTag := fs.String("locale", export.DefaultLocale, "number and date format of CSV values")
+	dryRun := fs.Bool("dry-run", false, "validate only")
+	batchSize := fs.Int("batch-size", importer.DefaultBatchSize, "rows per transaction")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: invoices-api import [flags] FILE\n\nFILE may be - for standard input.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one file is required")
+	}
+	path := fs.Arg(0)
+
+	format := importer.FormatCSV
+	if *formatName != "" {
+		parsed, err := importer.ParseFormat(*formatName)
+		if err != nil {
+			return err
+		}
+		format = parsed
+	} else if parsed, err := importer.ParseFormat(strings.TrimPrefix(filepath.Ext(path), ".")); err == nil {
+		format = parsed
+	}
+
+	locale, err := export.LookupLocale(*localeTag)
+	if err != nil {
+		return err
+	}
+
+	var source io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		source = file
+	}
+
+	db := openDB(cfg)
+	ctx, _, err := organizationContext(context.Background(), db, *org)
+	if err != nil {
+		return err
+	}
+
+	// A shared cache must learn about the imported invoices, so the import
+	// invalidates it like the server would.
+	invoiceCache, closeCache, err := app.NewCache(cfg.Cache)
+	if err != nil {
+		return err
+	}
+	defer closeCache()
+
+	repo := repository.NewInvoiceRepository(db, invoiceCache, cfg.Cache.TTL, len(cfg.Database.Replicas) > 0)
+	imp := importer.NewImporter(repo, validator.NewInvoiceValidator())
+	report, err := imp.Import(ctx, source, importer.Options{
+		Format:    format,
+		Locale:    locale,
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+		// Operators may import paid invoices.
+		AllowPaid: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range report.Errors {
+		for _, fieldErr := range rowErr.Errors {
+			fmt.Fprintf(os.Stderr, "row %d: %s: %s\n", rowErr.Row, fieldErr.Field, fieldErr.Message)
+		}
+	}
+
+	if report.DryRun {
+		fmt.Printf("Dry run: %d of %d rows are valid\n", report.ValidRows, report.TotalRows)
+	} else {
+		fmt.Printf("Imported %d of %d rows, %d failed\n", report.Imported, report.TotalRows, report.Failed)
+	}
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d row(s) were rejected", len(report.Errors))
+	}
+	return nil
+}
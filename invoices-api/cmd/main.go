@@ -2,45 +2,137 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"invoices-api/config"
-	"invoices-api/internal/app"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
 	"invoices-api/pkg/database"
-	"log"
+	"invoices-api/pkg/logging"
+	"invoices-api/pkg/middleware"
 	"os"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "Start the HTTP server (default)", runServe},
+		{"migrate", "Apply, roll back or create database migrations", runMigrate},
+		{"seed", "Upsert invoices from a fixture profile, a file or the fake data generator", runSeed},
+		{"import", "Import invoices from a CSV or JSONL file", runImport},
+		{"export", "Export invoices as CSV or XLSX", runExport},
+		{"create-user", "Create a user in an organization", runCreateUser},
+		{"rotate-keys", "Replace a user's API keys and optionally end their sessions", runRotateKeys},
+		{"check-config", "Validate the configuration and database connection", runCheckConfig},
+	}
+}
+
 func main() {
-	cfg := config.LoadConfig()
-
-	dbConfig := &database.DatabaseConfig{
-		Host:     cfg.DBHost,
-		User:     cfg.DBUser,
-		Password: cfg.DBPassword,
-		DBName:   cfg.DBName,
-		Port:     cfg.DBPort,
+	// Without a command the server starts, as it always has.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	db := database.ConnectDBWithRetry(dbConfig, 5)
+	if name == "help" {
+		usage()
+		return
+	}
 
-	application, err := app.New(db)
-	if err != nil {
-		log.Fatalf("Failed to create application: %v", err)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		cfg, args, err := config.Load(args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		// Logs go to standard error so that they never mix with the output
+		// of commands such as export.
+		if err := logging.Setup(logging.Config{
+			Level:  cfg.Logging.Level,
+			Format: cfg.Logging.Format,
+			Output: os.Stderr,
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		if err := cmd.run(cfg, args); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: invoices-api <command> [-config FILE] [-set KEY=VALUE]... [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "invoices-api <command> -h" for the flags of a command.`)
+}
 
-	if err := application.Start(port); err != nil {
-		log.Fatalf("Failed to start application: %v", err)
+// databaseConfig has been validated by config.Load, so the log level is
+// known to parse.
+func databaseConfig(cfg *config.Config) *database.DatabaseConfig {
+	logLevel, _ := database.ParseLogLevel(cfg.Logging.SQLLevel)
+	return &database.DatabaseConfig{
+		Host:             cfg.Database.Host,
+		User:             cfg.Database.User,
+		Password:         cfg.Database.Password,
+		DBName:           cfg.Database.Name,
+		Port:             strconv.Itoa(cfg.Database.Port),
+		SSLMode:          cfg.Database.SSLMode,
+		SSLRootCert:      cfg.Database.SSLRootCert,
+		SSLCert:          cfg.Database.SSLCert,
+		SSLKey:           cfg.Database.SSLKey,
+		StatementTimeout: cfg.Database.StatementTimeout,
+		Replicas:         cfg.Database.Replicas,
+		MaxOpenConns:     cfg.Database.MaxOpenConns,
+		MaxIdleConns:     cfg.Database.MaxIdleConns,
+		ConnMaxLifetime:  cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime:  cfg.Database.ConnMaxIdleTime,
+		LogLevel:         logLevel,
+		SlowQuery:        cfg.Logging.SlowQuery,
 	}
+}
 
-	<-application.WaitForShutdown()
+// openDB connects for a one-off command. Only slow queries and errors are
+// logged so that command output stays readable.
+func openDB(cfg *config.Config) *gorm.DB {
+	db := database.ConnectDBWithRetry(databaseConfig(cfg), cfg.Database.ConnectRetries)
+	return db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Warn)})
+}
 
-	// Graceful shutdown
-	if err := application.Shutdown(context.Background()); err != nil {
-		log.Printf("Error during shutdown: %v", err)
-		os.Exit(1)
+// organizationContext returns ctx scoped to the organization with the given
+// slug, as the Tenant middleware does for requests.
+func organizationContext(ctx context.Context, db *gorm.DB, slug string) (context.Context, *models.Organization, error) {
+	org, err := repository.NewOrganizationRepository(db).GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, nil, fmt.Errorf("organization %q: %w", slug, err)
 	}
+	return middleware.WithTenant(ctx, org.ID), org, nil
 }
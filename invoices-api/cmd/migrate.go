@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/pkg/database"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `Usage: invoices-api migrate <command> [flags]
+
+Commands:
+  up                 Apply all pending migrations
+  down [-steps N]    Roll back the last N migrations (default 1)
+  status             List migrations and when they were applied
+  create [-dir DIR] NAME
+                     Write empty up and down files for a new migration
+`
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return errors.New("missing command")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "create":
+		return migrateCreate(args)
+	case "up", "down", "status":
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	fs := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	fs.Parse(args)
+
+	db := openDB(cfg)
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		return migrateUp(db)
+	case "down":
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			return err
+		}
+		n, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		slog.Info("Rolled back migrations", "count", n)
+		return nil
+	default:
+		return migrateStatus(ctx, db)
+	}
+}
+
+// migrateUp applies pending migrations. Replicas starting at the same time
+// wait for each other on the migration lock.
+func migrateUp(db *gorm.DB) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	n, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		slog.Info("Applied migrations", "count", n)
+	} else {
+		slog.Info("Database schema is up to date")
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, db *gorm.DB) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
+
+func migrateCreate(args []string) error {
+	fs := flag.NewFlagSet("migrate create", flag.ExitOnError)
+	dir := fs.String("dir", "pkg/database/migrations", "migrations directory")
+	fs.Parse(args)
+
+	name := strings.Join(fs.Args(), " ")
+	if name == "" {
+		return errors.New("migration name is required")
+	}
+
+	paths, err := database.CreateMigration(*dir, name)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Println("Created", path)
+	}
+	return nil
+}
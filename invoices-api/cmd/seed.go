@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/pkg/database"
+	"strings"
+)
+
+func runSeed(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	profile := fs.String("profile", "", "embedded fixture profile: "+strings.Join(database.SeedProfiles(), ", "))
+	file := fs.String("file", "", "fixture file (.yaml, .yml or .json)")
+	generate := fs.Int("generate", 0, "number of fake invoices to generate")
+	org := fs.String("org", "default", "organization slug for generated invoices")
+	randomSeed := fs.Int64("random-seed", database.DefaultGenerateSeed, "seed of the fake data generator")
+	firstNumber := fs.Int("first-number", database.DefaultGenerateFirstNumber, "invoice number of the first generated invoice")
+	days := fs.Int("days", database.DefaultGenerateDays, "number of days from 2024-01-01 generated dates are spread over")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: invoices-api seed (-profile NAME | -file FILE | -generate N) [flags]\n\nInvoices are upserted by invoice number, so seeding is safe to repeat.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	sources := 0
+	for _, set := range []bool{*profile != "", *file != "", *generate != 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		fs.Usage()
+		return errors.New("exactly one of -profile, -file or -generate is required")
+	}
+
+	switch {
+	case *profile != "":
+		fixture, err := database.LoadProfile(*profile)
+		if err != nil {
+			return err
+		}
+		_, err = database.Seed(openDB(cfg), fixture)
+		return err
+
+	case *file != "":
+		fixture, err := database.LoadFixtureFile(*file)
+		if err != nil {
+			return err
+		}
+		_, err = database.Seed(openDB(cfg), fixture)
+		return err
+
+	default:
+		invoices, err := database.GenerateInvoices(database.GenerateOptions{
+			Count:       *generate,
+			Seed:        *randomSeed,
+			FirstNumber: *firstNumber,
+			Days:        *days,
+		})
+		if err != nil {
+			return err
+		}
+		return database.SeedInvoices(openDB(cfg), *org, "", invoices)
+	}
+}
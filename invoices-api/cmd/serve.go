@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/app"
+	"invoices-api/pkg/database"
+	"invoices-api/pkg/tracing"
+	"log/slog"
+	"strconv"
+)
+
+func runServe(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.Int("port", cfg.Server.Port, "port to listen on")
+	fs.Parse(args)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: cfg.Tracing.ServiceName,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	db := database.ConnectDBWithRetry(databaseConfig(cfg), cfg.Database.ConnectRetries)
+
+	if cfg.Database.MigrateOnStart {
+		if err := migrateUp(db); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	if cfg.Database.SeedProfile != "" {
+		if _, err := database.SeedProfile(db, cfg.Database.SeedProfile); err != nil {
+			slog.Warn("Failed to seed database", "profile", cfg.Database.SeedProfile, "error", err)
+		}
+	}
+
+	application, err := app.New(db, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := application.Start(strconv.Itoa(*port)); err != nil {
+		return fmt.Errorf("failed to start application: %w", err)
+	}
+
+	<-application.WaitForShutdown()
+
+	// Graceful shutdown, then flush the spans of the last requests
+	err = application.Shutdown(context.Background())
+	if tracingErr := shutdownTracing(context.Background()); tracingErr != nil {
+		slog.Warn("Failed to flush traces", "error", tracingErr)
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/auth"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func runCreateUser(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	email := fs.String("email", "", "email address (required)")
+	name := fs.String("name", "", "display name")
+	role := fs.String("role", middleware.RoleViewer, "role")
+	org := fs.String("org", "default", "organization slug")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input instead of generating one")
+	fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	policy, err := middleware.LoadPolicy(cfg.Auth.RBACPolicyFile)
+	if err != nil {
+		return err
+	}
+	if !policy.HasRole(*role) {
+		return fmt.Errorf("unknown role %q, expected one of %s", *role, strings.Join(policy.Roles(), ", "))
+	}
+
+	password, generated := "", false
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	} else {
+		buf := make([]byte, 18)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		password, generated = base64.RawURLEncoding.EncodeToString(buf), true
+	}
+
+	db := openDB(cfg)
+	ctx, organization, err := organizationContext(context.Background(), db, *org)
+	if err != nil {
+		return err
+	}
+
+	service := auth.NewService(repository.NewUserRepository(db), auth.Config{})
+	user, err := service.CreateUser(ctx, organization.ID, *email, password, *name, *role)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s user %s (id %d) in %s\n", user.Role, user.Email, user.ID, organization.Slug)
+	if generated {
+		fmt.Printf("Password: %s\n", password)
+	}
+	return nil
+}
+
+func runRotateKeys(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	email := fs.String("email", "", "owner of the keys (required)")
+	sessions := fs.Bool("sessions", false, "also revoke the user's refresh tokens")
+	fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	policy, err := middleware.LoadPolicy(cfg.Auth.RBACPolicyFile)
+	if err != nil {
+		return err
+	}
+
+	db := openDB(cfg)
+	users := repository.NewUserRepository(db)
+
+	user, err := users.GetByEmail(context.Background(), *email)
+	if err != nil {
+		return fmt.Errorf("user %q: %w", *email, err)
+	}
+	ctx := middleware.WithTenant(context.Background(), user.TenantID)
+
+	keys := auth.NewAPIKeyService(repository.NewAPIKeyRepository(db), users, policy)
+	rotated, rotateErr := keys.Rotate(ctx, &middleware.Principal{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Role:     user.Role,
+		TenantID: user.TenantID,
+	})
+
+	if len(rotated) > 0 {
+		fmt.Println("New keys are shown only once:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tKEY")
+		for _, key := range rotated {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", key.ID, key.Name, key.Key)
+		}
+		w.Flush()
+	} else if rotateErr == nil {
+		fmt.Println("No active API keys to rotate")
+	}
+
+	if *sessions {
+		if err := auth.NewService(users, auth.Config{}).RevokeSessions(ctx, user.ID); err != nil {
+			return errors.Join(rotateErr, err)
+		}
+		fmt.Println("Revoked all refresh tokens")
+	}
+
+	return rotateErr
+}
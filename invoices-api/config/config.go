@@ -1,31 +1,275 @@
 package config
 
 import (
-	"os"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"log/slog"
+	"time"
 )
 
+// Config is assembled from, in increasing order of precedence: the defaults
+// below, a YAML file, environment variables and command line flags. See
+// Load.
 type Config struct {
-	DBHost     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBPort     string
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Webhooks    WebhookConfig     `yaml:"webhooks"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Mail        MailConfig        `yaml:"mail"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Features    FeaturesConfig    `yaml:"features"`
 }
 
-func LoadConfig() *Config {
+// ServerConfig configures the HTTP server. Zero read, write and idle
+// timeouts mean no timeout.
+type ServerConfig struct {
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// BodyLimit is the maximum request body size in bytes.
+	BodyLimit   int      `yaml:"body_limit"`
+	CORSOrigins []string `yaml:"cors_origins"`
+	// ListCacheControl and ItemCacheControl are the Cache-Control headers
+	// of invoice listings and single invoices; empty omits the header.
+	ListCacheControl string `yaml:"list_cache_control"`
+	ItemCacheControl string `yaml:"item_cache_control"`
+	// ProxyHeader, e.g. X-Real-IP, holds the client address of requests
+	// that come from one of TrustedProxies, given as addresses or CIDR
+	// ranges. Without it the address of the connection is used.
+	ProxyHeader    string   `yaml:"proxy_header"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	// SSLMode is a libpq sslmode. The certificate paths are optional.
+	SSLMode     string `yaml:"ssl_mode"`
+	SSLRootCert string `yaml:"ssl_root_cert"`
+	SSLCert     string `yaml:"ssl_cert"`
+	SSLKey      string `yaml:"ssl_key"`
+	// StatementTimeout aborts longer statements; zero disables it.
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+	// Replicas are "host" or "host:port" addresses of read replicas, which
+	// serve invoice listings, search, exports and reports.
+	Replicas        []string      `yaml:"replicas"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	ConnectRetries  int           `yaml:"connect_retries"`
+	// MigrateOnStart applies pending migrations before the server starts.
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+	// SeedProfile names the fixture profile seeded on start; empty disables
+	// seeding.
+	SeedProfile string `yaml:"seed_profile"`
+}
+
+// CacheConfig configures the cache of invoices and invoice listings. The
+// memory backend is private to each process; instances sharing a database
+// should share a Redis cache instead.
+type CacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+	// Backend is memory or redis.
+	Backend string `yaml:"backend"`
+	// MaxEntries bounds the memory backend, which evicts the least recently
+	// used entries beyond it.
+	MaxEntries int `yaml:"max_entries"`
+	// RedisURL is a redis:// or rediss:// URL, used by the redis backend.
+	RedisURL string `yaml:"redis_url"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	AdminEmail      string        `yaml:"admin_email"`
+	AdminPassword   string        `yaml:"admin_password"`
+	RBACPolicyFile  string        `yaml:"rbac_policy_file"`
+	// InsecureRandomSecret allows an empty JWTSecret for development; a
+	// random secret is generated instead.
+	InsecureRandomSecret bool `yaml:"insecure_random_secret"`
+}
+
+// RateLimitConfig limits use the "<count>/<s|m|h>[:<burst>]" format, or
+// "off".
+type RateLimitConfig struct {
+	Auth  string `yaml:"auth"`
+	IP    string `yaml:"ip"`
+	API   string `yaml:"api"`
+	Heavy string `yaml:"heavy"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type WebhookConfig struct {
+	Timeout     time.Duration `yaml:"timeout"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	// AllowPrivateNetworks lets subscribers receive deliveries on loopback,
+	// private and link-local addresses.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+}
+
+type OutboxConfig struct {
+	// Sinks lists any of: webhooks, bus, log.
+	Sinks     []string      `yaml:"sinks"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// MailConfig disables email delivery while SMTPHost is empty. SMTPTLS is one
+// of none, starttls or tls.
+type MailConfig struct {
+	SMTPHost     string        `yaml:"smtp_host"`
+	SMTPPort     int           `yaml:"smtp_port"`
+	SMTPUsername string        `yaml:"smtp_username"`
+	SMTPPassword string        `yaml:"smtp_password"`
+	SMTPTLS      string        `yaml:"smtp_tls"`
+	From         string        `yaml:"from"`
+	Locale       string        `yaml:"locale"`
+	TemplateDir  string        `yaml:"template_dir"`
+	PaymentTerms time.Duration `yaml:"payment_terms"`
+	// DunningSchedule lists reminder offsets after the due date, e.g.
+	// "7d,14d,30d", or "off".
+	DunningSchedule string        `yaml:"dunning_schedule"`
+	DunningInterval time.Duration `yaml:"dunning_interval"`
+}
+
+type LoggingConfig struct {
+	// Level is debug, info, warn or error.
+	Level string `yaml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format"`
+	// SQLLevel selects what GORM reports: silent, error, warn or info.
+	// Every query, reported with info, is logged at the debug level.
+	SQLLevel  string        `yaml:"sql_level"`
+	SlowQuery time.Duration `yaml:"slow_query"`
+	AccessLog bool          `yaml:"access_log"`
+	// RequestBodies adds request bodies to the access log, with credentials
+	// redacted.
+	RequestBodies bool `yaml:"request_bodies"`
+}
+
+// TracingConfig configures OpenTelemetry tracing. Exporter is otlp, which
+// sends spans to the OTLP/HTTP collector at Endpoint, or stdout.
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	ServiceName string  `yaml:"service_name"`
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type FeaturesConfig struct {
+	Swagger bool `yaml:"swagger"`
+	Metrics bool `yaml:"metrics"`
+}
+
+// Default returns the configuration used when nothing else is set.
+func Default() *Config {
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "postgres"),
-		DBName:     getEnv("DB_NAME", "invoice_db"),
-		DBPort:     getEnv("DB_PORT", "5432"),
+		Server: ServerConfig{
+			Port:            3000,
+			ReadTimeout:     30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+			BodyLimit:       4 * 1024 * 1024,
+			CORSOrigins:     []string{"*"},
+			// Clients may store responses but must revalidate them, which
+			// the ETag makes cheap.
+			ListCacheControl: "private, no-cache",
+			ItemCacheControl: "private, no-cache",
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "invoice_db",
+			SSLMode:         "disable",
+			MaxOpenConns:    100,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
+			ConnectRetries:  5,
+			MigrateOnStart:  true,
+		},
+		Cache: CacheConfig{
+			Enabled:    true,
+			TTL:        30 * time.Second,
+			Backend:    "memory",
+			MaxEntries: 10000,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Auth:  "10/m",
+			IP:    "600/m",
+			API:   "300/m",
+			Heavy: "20/m",
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Webhooks: WebhookConfig{
+			Timeout:     10 * time.Second,
+			MaxAttempts: 10,
+		},
+		Outbox: OutboxConfig{
+			Sinks:     []string{"webhooks", "bus"},
+			Retention: 72 * time.Hour,
+		},
+		Mail: MailConfig{
+			SMTPPort:        587,
+			SMTPTLS:         "starttls",
+			Locale:          "en-US",
+			PaymentTerms:    30 * 24 * time.Hour,
+			DunningSchedule: "7d,14d,30d",
+			DunningInterval: time.Hour,
+		},
+		Logging: LoggingConfig{
+			Level:     "info",
+			Format:    "json",
+			SQLLevel:  "info",
+			SlowQuery: 200 * time.Millisecond,
+			AccessLog: true,
+		},
+		Tracing: TracingConfig{
+			ServiceName: "invoices-api",
+			Exporter:    "otlp",
+			Endpoint:    "http://localhost:4318",
+			SampleRatio: 1,
+		},
+		Features: FeaturesConfig{
+			Swagger: true,
+			Metrics: true,
+		},
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// randomSecret is only used when no JWT secret is configured and
+// insecure_random_secret is set. Tokens signed with it do not survive a
+// restart and are not shared between instances.
+func randomSecret() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("Failed to generate JWT secret: %v", err)
 	}
-	return value
+
+	slog.Warn("JWT_SECRET is not set, using a random secret; tokens will be invalid after a restart")
+	return hex.EncodeToString(buf)
 }
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting binds a configuration key to its environment variable and to the
+// field holding its value.
+type setting struct {
+	// key is the dotted YAML path, e.g. "server.port", also used by -set.
+	key string
+	env string
+	// secret settings may also be read from the file named by <env>_FILE.
+	secret bool
+	value  any
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"server.port", "PORT", false, &c.Server.Port},
+		{"server.read_timeout", "SERVER_READ_TIMEOUT", false, &c.Server.ReadTimeout},
+		{"server.write_timeout", "SERVER_WRITE_TIMEOUT", false, &c.Server.WriteTimeout},
+		{"server.idle_timeout", "SERVER_IDLE_TIMEOUT", false, &c.Server.IdleTimeout},
+		{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", false, &c.Server.ShutdownTimeout},
+		{"server.body_limit", "SERVER_BODY_LIMIT", false, &c.Server.BodyLimit},
+		{"server.cors_origins", "CORS_ORIGINS", false, &c.Server.CORSOrigins},
+		{"server.list_cache_control", "SERVER_LIST_CACHE_CONTROL", false, &c.Server.ListCacheControl},
+		{"server.item_cache_control", "SERVER_ITEM_CACHE_CONTROL", false, &c.Server.ItemCacheControl},
+		{"server.proxy_header", "SERVER_PROXY_HEADER", false, &c.Server.ProxyHeader},
+		{"server.trusted_proxies", "SERVER_TRUSTED_PROXIES", false, &c.Server.TrustedProxies},
+
+		{"database.host", "DB_HOST", false, &c.Database.Host},
+		{"database.port", "DB_PORT", false, &c.Database.Port},
+		{"database.user", "DB_USER", false, &c.Database.User},
+		{"database.password", "DB_PASSWORD", true, &c.Database.Password},
+		{"database.name", "DB_NAME", false, &c.Database.Name},
+		{"database.ssl_mode", "DB_SSL_MODE", false, &c.Database.SSLMode},
+		{"database.ssl_root_cert", "DB_SSL_ROOT_CERT", false, &c.Database.SSLRootCert},
+		{"database.ssl_cert", "DB_SSL_CERT", false, &c.Database.SSLCert},
+		{"database.ssl_key", "DB_SSL_KEY", false, &c.Database.SSLKey},
+		{"database.statement_timeout", "DB_STATEMENT_TIMEOUT", false, &c.Database.StatementTimeout},
+		{"database.replicas", "DB_REPLICAS", false, &c.Database.Replicas},
+		{"database.max_open_conns", "DB_MAX_OPEN_CONNS", false, &c.Database.MaxOpenConns},
+		{"database.max_idle_conns", "DB_MAX_IDLE_CONNS", false, &c.Database.MaxIdleConns},
+		{"database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", false, &c.Database.ConnMaxLifetime},
+		{"database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", false, &c.Database.ConnMaxIdleTime},
+		{"database.connect_retries", "DB_CONNECT_RETRIES", false, &c.Database.ConnectRetries},
+		{"database.migrate_on_start", "MIGRATE_ON_START", false, &c.Database.MigrateOnStart},
+		{"database.seed_profile", "SEED_PROFILE", false, &c.Database.SeedProfile},
+
+		{"cache.enabled", "CACHE_ENABLED", false, &c.Cache.Enabled},
+		{"cache.ttl", "CACHE_TTL", false, &c.Cache.TTL},
+		{"cache.backend", "CACHE_BACKEND", false, &c.Cache.Backend},
+		{"cache.max_entries", "CACHE_MAX_ENTRIES", false, &c.Cache.MaxEntries},
+		{"cache.redis_url", "CACHE_REDIS_URL", true, &c.Cache.RedisURL},
+
+		{"auth.jwt_secret", "JWT_SECRET", true, &c.Auth.JWTSecret},
+		{"auth.insecure_random_secret", "JWT_INSECURE_RANDOM_SECRET", false, &c.Auth.InsecureRandomSecret},
+		{"auth.access_token_ttl", "ACCESS_TOKEN_TTL", false, &c.Auth.AccessTokenTTL},
+		{"auth.refresh_token_ttl", "REFRESH_TOKEN_TTL", false, &c.Auth.RefreshTokenTTL},
+		{"auth.admin_email", "ADMIN_EMAIL", false, &c.Auth.AdminEmail},
+		{"auth.admin_password", "ADMIN_PASSWORD", true, &c.Auth.AdminPassword},
+		{"auth.rbac_policy_file", "RBAC_POLICY_FILE", false, &c.Auth.RBACPolicyFile},
+
+		{"rate_limit.auth", "RATE_LIMIT_AUTH", false, &c.RateLimit.Auth},
+		{"rate_limit.ip", "RATE_LIMIT_IP", false, &c.RateLimit.IP},
+		{"rate_limit.api", "RATE_LIMIT_API", false, &c.RateLimit.API},
+		{"rate_limit.heavy", "RATE_LIMIT_HEAVY", false, &c.RateLimit.Heavy},
+
+		{"idempotency.ttl", "IDEMPOTENCY_TTL", false, &c.Idempotency.TTL},
+
+		{"webhooks.timeout", "WEBHOOK_TIMEOUT", false, &c.Webhooks.Timeout},
+		{"webhooks.max_attempts", "WEBHOOK_MAX_ATTEMPTS", false, &c.Webhooks.MaxAttempts},
+		{"webhooks.allow_private_networks", "WEBHOOK_ALLOW_PRIVATE_NETWORKS", false, &c.Webhooks.AllowPrivateNetworks},
+
+		{"outbox.sinks", "OUTBOX_SINKS", false, &c.Outbox.Sinks},
+		{"outbox.retention", "OUTBOX_RETENTION", false, &c.Outbox.Retention},
+
+		{"mail.smtp_host", "SMTP_HOST", false, &c.Mail.SMTPHost},
+		{"mail.smtp_port", "SMTP_PORT", false, &c.Mail.SMTPPort},
+		{"mail.smtp_username", "SMTP_USERNAME", false, &c.Mail.SMTPUsername},
+		{"mail.smtp_password", "SMTP_PASSWORD", true, &c.Mail.SMTPPassword},
+		{"mail.smtp_tls", "SMTP_TLS", false, &c.Mail.SMTPTLS},
+		{"mail.from", "MAIL_FROM", false, &c.Mail.From},
+		{"mail.locale", "MAIL_LOCALE", false, &c.Mail.Locale},
+		{"mail.template_dir", "MAIL_TEMPLATE_DIR", false, &c.Mail.TemplateDir},
+		{"mail.payment_terms", "PAYMENT_TERMS", false, &c.Mail.PaymentTerms},
+		{"mail.dunning_schedule", "DUNNING_SCHEDULE", false, &c.Mail.DunningSchedule},
+		{"mail.dunning_interval", "DUNNING_INTERVAL", false, &c.Mail.DunningInterval},
+
+		{"logging.level", "LOG_LEVEL", false, &c.Logging.Level},
+		{"logging.format", "LOG_FORMAT", false, &c.Logging.Format},
+		{"logging.sql_level", "LOG_SQL_LEVEL", false, &c.Logging.SQLLevel},
+		{"logging.slow_query", "LOG_SLOW_QUERY", false, &c.Logging.SlowQuery},
+		{"logging.access_log", "LOG_ACCESS", false, &c.Logging.AccessLog},
+		{"logging.request_bodies", "LOG_REQUEST_BODIES", false, &c.Logging.RequestBodies},
+
+		{"tracing.enabled", "TRACING_ENABLED", false, &c.Tracing.Enabled},
+		{"tracing.service_name", "TRACING_SERVICE_NAME", false, &c.Tracing.ServiceName},
+		{"tracing.exporter", "TRACING_EXPORTER", false, &c.Tracing.Exporter},
+		{"tracing.endpoint", "TRACING_ENDPOINT", false, &c.Tracing.Endpoint},
+		{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", false, &c.Tracing.SampleRatio},
+
+		{"features.swagger", "FEATURE_SWAGGER", false, &c.Features.Swagger},
+		{"features.metrics", "FEATURE_METRICS", false, &c.Features.Metrics},
+	}
+}
+
+// Load builds the configuration for a command. It removes the -config FILE
+// and -set KEY=VALUE flags from args, wherever they appear, and returns the
+// remaining arguments. The file defaults to $CONFIG_FILE.
+func Load(args []string) (*Config, []string, error) {
+	path, overrides, rest, err := extractFlags(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cfg.loadEnv(os.LookupEnv); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.apply(overrides); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.Auth.JWTSecret == "" && cfg.Auth.InsecureRandomSecret {
+		cfg.Auth.JWTSecret = randomSecret()
+	}
+	return cfg, rest, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv applies environment variables. Empty variables are ignored, as
+// they always have been. A secret is read from <env>_FILE, with trailing
+// newlines removed, when that variable is set instead.
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, s := range c.settings() {
+		value, ok := lookup(s.env)
+		ok = ok && value != ""
+
+		if s.secret {
+			if file, fileOK := lookup(s.env + "_FILE"); fileOK && file != "" {
+				if ok {
+					errs = append(errs, fmt.Errorf("%s and %s_FILE are both set", s.env, s.env))
+					continue
+				}
+				data, err := os.ReadFile(file)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s_FILE: %w", s.env, err))
+					continue
+				}
+				value, ok = strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+
+		if !ok {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// apply sets "key=value" overrides given with -set.
+func (c *Config) apply(overrides []string) error {
+	settings := map[string]setting{}
+	for _, s := range c.settings() {
+		settings[s.key] = s
+	}
+
+	var errs []error
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("-set %s: expected KEY=VALUE", override))
+			continue
+		}
+		s, ok := settings[strings.TrimSpace(key)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("-set %s: unknown key %q", override, key))
+			continue
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("-set %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s setting) set(value string) error {
+	switch target := s.value.(type) {
+	case *string:
+		*target = value
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*target = n
+	case *float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*target = f
+	case *bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*target = b
+	case *time.Duration:
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 1h", value)
+		}
+		*target = d
+	case *[]string:
+		// Lists are comma separated.
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*target = items
+	default:
+		return fmt.Errorf("unsupported type %T", s.value)
+	}
+	return nil
+}
+
+// extractFlags pulls -config and -set out of args. Both accept one or two
+// dashes and either "=VALUE" or a separate argument. Everything after "--" is
+// left alone.
+func extractFlags(args []string) (path string, overrides, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "config" && name != "set") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 == len(args) {
+				return "", nil, nil, fmt.Errorf("flag -%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		if name == "config" {
+			path = value
+		} else {
+			overrides = append(overrides, value)
+		}
+	}
+	return path, overrides, rest, nil
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var (
+	cacheBackends    = []string{"memory", "redis"}
+	logFormats       = []string{"json", "text"}
+	logLevels        = []string{"debug", "info", "warn", "error"}
+	smtpTLSModes     = []string{"none", "starttls", "tls"}
+	sqlLogLevels     = []string{"silent", "error", "warn", "info"}
+	tracingExporters = []string{"otlp", "stdout"}
+	sslModes         = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+)
+
+// Validate reports every invalid setting at once, each prefixed with its key.
+// Settings that need other packages to interpret, such as rate limits and
+// the RBAC policy, are checked when the application starts.
+func (c *Config) Validate() error {
+	v := &validation{}
+
+	v.port("server.port", c.Server.Port)
+	v.nonNegative("server.read_timeout", c.Server.ReadTimeout)
+	v.nonNegative("server.write_timeout", c.Server.WriteTimeout)
+	v.nonNegative("server.idle_timeout", c.Server.IdleTimeout)
+	v.positiveDuration("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	v.positive("server.body_limit", c.Server.BodyLimit)
+	if len(c.Server.CORSOrigins) == 0 {
+		v.fail("server.cors_origins", "must list at least one origin, or *")
+	}
+	if c.Server.ProxyHeader != "" && len(c.Server.TrustedProxies) == 0 {
+		v.fail("server.trusted_proxies", "must list the proxies allowed to set server.proxy_header")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				v.fail("server.trusted_proxies", "invalid address or range %q", proxy)
+			}
+		}
+	}
+
+	v.required("database.host", c.Database.Host)
+	v.port("database.port", c.Database.Port)
+	v.required("database.user", c.Database.User)
+	v.required("database.name", c.Database.Name)
+	v.oneOf("database.ssl_mode", c.Database.SSLMode, sslModes)
+	if (c.Database.SSLCert == "") != (c.Database.SSLKey == "") {
+		v.fail("database.ssl_cert", "ssl_cert and ssl_key must be set together")
+	}
+	for _, path := range []struct{ key, value string }{
+		{"database.ssl_root_cert", c.Database.SSLRootCert},
+		{"database.ssl_cert", c.Database.SSLCert},
+		{"database.ssl_key", c.Database.SSLKey},
+	} {
+		v.readable(path.key, path.value)
+	}
+	v.nonNegative("database.statement_timeout", c.Database.StatementTimeout)
+	for _, replica := range c.Database.Replicas {
+		if host, port, err := net.SplitHostPort(replica); err == nil {
+			if p, err := strconv.Atoi(port); host == "" || err != nil || p < 1 || p > 65535 {
+				v.fail("database.replicas", "invalid address %q", replica)
+			}
+		}
+	}
+	v.positive("database.max_open_conns", c.Database.MaxOpenConns)
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		v.fail("database.max_idle_conns", "must be between 0 and database.max_open_conns")
+	}
+	v.positiveDuration("database.conn_max_lifetime", c.Database.ConnMaxLifetime)
+	v.nonNegative("database.conn_max_idle_time", c.Database.ConnMaxIdleTime)
+	v.positive("database.connect_retries", c.Database.ConnectRetries)
+
+	if c.Cache.Enabled {
+		v.positiveDuration("cache.ttl", c.Cache.TTL)
+		v.oneOf("cache.backend", c.Cache.Backend, cacheBackends)
+		switch c.Cache.Backend {
+		case "memory":
+			v.positive("cache.max_entries", c.Cache.MaxEntries)
+		case "redis":
+			v.required("cache.redis_url", c.Cache.RedisURL)
+		}
+	}
+
+	if c.Auth.JWTSecret == "" && !c.Auth.InsecureRandomSecret {
+		v.fail("auth.jwt_secret", "is required; set auth.insecure_random_secret to use a random secret in development")
+	}
+	v.positiveDuration("auth.access_token_ttl", c.Auth.AccessTokenTTL)
+	v.positiveDuration("auth.refresh_token_ttl", c.Auth.RefreshTokenTTL)
+	if (c.Auth.AdminEmail == "") != (c.Auth.AdminPassword == "") {
+		v.fail("auth.admin_email", "admin_email and admin_password must be set together")
+	}
+
+	v.positiveDuration("idempotency.ttl", c.Idempotency.TTL)
+
+	v.positiveDuration("webhooks.timeout", c.Webhooks.Timeout)
+	v.positive("webhooks.max_attempts", c.Webhooks.MaxAttempts)
+
+	v.positiveDuration("outbox.retention", c.Outbox.Retention)
+
+	if c.Mail.SMTPHost != "" {
+		v.port("mail.smtp_port", c.Mail.SMTPPort)
+		v.oneOf("mail.smtp_tls", c.Mail.SMTPTLS, smtpTLSModes)
+		v.required("mail.from", c.Mail.From)
+	}
+	v.positiveDuration("mail.payment_terms", c.Mail.PaymentTerms)
+	v.positiveDuration("mail.dunning_interval", c.Mail.DunningInterval)
+
+	v.oneOf("logging.level", c.Logging.Level, logLevels)
+	v.oneOf("logging.format", c.Logging.Format, logFormats)
+	v.oneOf("logging.sql_level", c.Logging.SQLLevel, sqlLogLevels)
+	v.positiveDuration("logging.slow_query", c.Logging.SlowQuery)
+
+	if c.Tracing.Enabled {
+		v.required("tracing.service_name", c.Tracing.ServiceName)
+		v.oneOf("tracing.exporter", c.Tracing.Exporter, tracingExporters)
+		if c.Tracing.Exporter == "otlp" {
+			if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				v.fail("tracing.endpoint", "must be an http or https URL, got %q", c.Tracing.Endpoint)
+			}
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			v.fail("tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+		}
+	}
+
+	return v.err()
+}
+
+type validation struct {
+	errs []error
+}
+
+func (v *validation) fail(key, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (v *validation) required(key, value string) {
+	if value == "" {
+		v.fail(key, "is required")
+	}
+}
+
+func (v *validation) port(key string, value int) {
+	if value < 1 || value > 65535 {
+		v.fail(key, "must be between 1 and 65535, got %d", value)
+	}
+}
+
+func (v *validation) positive(key string, value int) {
+	if value < 1 {
+		v.fail(key, "must be at least 1, got %d", value)
+	}
+}
+
+func (v *validation) positiveDuration(key string, value time.Duration) {
+	if value <= 0 {
+		v.fail(key, "must be a positive duration, got %s", value)
+	}
+}
+
+func (v *validation) nonNegative(key string, value time.Duration) {
+	if value < 0 {
+		v.fail(key, "must not be negative, got %s", value)
+	}
+}
+
+// readable checks that an optional file exists.
+func (v *validation) readable(key, path string) {
+	if path == "" {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		v.fail(key, "%v", err)
+	}
+}
+
+func (v *validation) oneOf(key, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		v.fail(key, "must be one of %v, got %q", allowed, value)
+	}
+}
+
+func (v *validation) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(v.errs...))
+}
@@ -2,38 +2,62 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/auth"
+	"invoices-api/internal/cache"
 	"invoices-api/internal/docs"
+	"invoices-api/internal/events"
+	"invoices-api/internal/export"
 	"invoices-api/internal/handlers"
+	"invoices-api/internal/mailer"
+	"invoices-api/internal/models"
 	"invoices-api/internal/repository"
+	"invoices-api/internal/webhooks"
 	"invoices-api/pkg/middleware"
 	"invoices-api/pkg/validator"
+	"log/slog"
+	"net/mail"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
 	swagger "github.com/gofiber/swagger"
 	"gorm.io/gorm"
 )
 
-const (
-	shutdownTimeout = 30 * time.Second
-)
+const idempotencyPurgeInterval = time.Hour
 
 type App struct {
 	fiber    *fiber.App
 	db       *gorm.DB
+	config   *config.Config
 	shutdown chan os.Signal
+	bus      *events.Bus
+
+	cache      cache.Cache
+	closeCache func() error
+
+	// workers run in the background from Start until Shutdown, which waits
+	// for them to return.
+	workers        []func(ctx context.Context)
+	stopWorkers    context.CancelFunc
+	runningWorkers sync.WaitGroup
 }
 
-func New(db *gorm.DB) (*App, error) {
+func New(db *gorm.DB, cfg *config.Config) (*App, error) {
 	app := &App{
 		db:       db,
+		config:   cfg,
 		shutdown: make(chan os.Signal, 1),
+		bus:      events.NewBus(),
 	}
 
 	if err := app.initialize(); err != nil {
@@ -47,6 +71,18 @@ func (a *App) initialize() error {
 	a.fiber = fiber.New(fiber.Config{
 		ErrorHandler: middleware.ErrorHandler,
 		AppName:      "Invoice API v1.0",
+		ReadTimeout:  a.config.Server.ReadTimeout,
+		WriteTimeout: a.config.Server.WriteTimeout,
+		IdleTimeout:  a.config.Server.IdleTimeout,
+		BodyLimit:    a.config.Server.BodyLimit,
+		// The client address, which rate limits are keyed by, is only taken
+		// from the proxy header of requests sent by a trusted proxy.
+		ProxyHeader:             a.config.Server.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          a.config.Server.TrustedProxies,
+		EnableIPValidation:      true,
+		// Startup is logged through slog instead of fiber's banner.
+		DisableStartupMessage: true,
 	})
 
 	a.setupMiddleware()
@@ -55,50 +91,370 @@ func (a *App) initialize() error {
 		return fmt.Errorf("failed to setup handlers: %w", err)
 	}
 
-	a.setupSwagger()
+	if a.config.Features.Swagger {
+		a.setupSwagger()
+	}
 
 	return nil
 }
 
 func (a *App) setupMiddleware() {
-	a.fiber.Use(middleware.PrometheusMiddleware())
-	a.fiber.Get("/metrics", middleware.PrometheusHandler())
+	a.fiber.Use(middleware.RequestID())
+	a.fiber.Use(middleware.Tracing())
 
-	a.fiber.Use(fiberlogger.New(fiberlogger.Config{
-		Format:     `{"time":"${time}","pid":"${pid}","status":${status},"method":"${method}","path":"${path}","latency":"${latency}","error":"${error}"}` + "\n",
-		TimeFormat: time.RFC3339,
-	}))
+	if a.config.Features.Metrics {
+		a.fiber.Use(middleware.PrometheusMiddleware())
+		a.fiber.Get("/metrics", middleware.PrometheusHandler())
+	}
+
+	if a.config.Logging.AccessLog {
+		a.fiber.Use(middleware.RequestLogger(middleware.RequestLoggerConfig{
+			LogBodies: a.config.Logging.RequestBodies,
+		}))
+	}
 
 	a.fiber.Use(middleware.RecoverMiddleware())
 
 	a.fiber.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowOrigins:  strings.Join(a.config.Server.CORSOrigins, ","),
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Tenant-ID, Idempotency-Key, If-None-Match, If-Modified-Since, X-Request-ID, traceparent, tracestate",
+		ExposeHeaders: "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Idempotent-Replayed, ETag, X-Request-ID",
 	}))
 }
 
 func (a *App) setupHandlers() error {
+	policy, err := middleware.LoadPolicy(a.config.Auth.RBACPolicyFile)
+	if err != nil {
+		return err
+	}
+	if !policy.HasRole(middleware.RolePlatformAdmin) {
+		return fmt.Errorf("RBAC policy must define the %s role given to the bootstrap admin", middleware.RolePlatformAdmin)
+	}
+
+	a.cache, a.closeCache, err = NewCache(a.config.Cache)
+	if err != nil {
+		return err
+	}
+
 	validator := validator.NewInvoiceValidator()
-	repo := repository.NewInvoiceRepository(a.db)
-	invoiceHandler := handlers.NewInvoiceHandler(repo, validator)
+	repo := repository.NewInvoiceRepository(a.db, a.cache, a.config.Cache.TTL, len(a.config.Database.Replicas) > 0)
+	invoiceHandler := handlers.NewInvoiceHandler(repo, validator, policy)
+	reportRepo := repository.NewReportRepository(a.db)
+	reportHandler := handlers.NewReportHandler(reportRepo)
+	if err := a.registerMetrics(reportRepo); err != nil {
+		return fmt.Errorf("failed to register metrics: %w", err)
+	}
 	healthHandler := handlers.NewHealthHandler(a.db)
 
+	userRepo := repository.NewUserRepository(a.db)
+	authService := auth.NewService(userRepo, auth.Config{
+		Secret:          []byte(a.config.Auth.JWTSecret),
+		AccessTokenTTL:  a.config.Auth.AccessTokenTTL,
+		RefreshTokenTTL: a.config.Auth.RefreshTokenTTL,
+	})
+	authHandler := handlers.NewAuthHandler(authService, userRepo)
+
+	orgRepo := repository.NewOrganizationRepository(a.db)
+	organizationHandler := handlers.NewOrganizationHandler(orgRepo)
+
+	defaultOrg, err := orgRepo.GetBySlug(context.Background(), models.DefaultOrganizationSlug)
+	if err != nil {
+		return fmt.Errorf("failed to load default organization: %w", err)
+	}
+
+	if err := authService.EnsureAdmin(context.Background(), defaultOrg.ID, a.config.Auth.AdminEmail, a.config.Auth.AdminPassword, "Administrator"); err != nil {
+		if !errors.Is(err, auth.ErrNoAdminCredentials) {
+			return fmt.Errorf("failed to create admin user: %w", err)
+		}
+		slog.Warn("Nobody will be able to log in: " + err.Error())
+	}
+
+	apiKeyService := auth.NewAPIKeyService(repository.NewAPIKeyRepository(a.db), userRepo, policy)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	authenticate := middleware.Authenticate(middleware.AuthConfig{
+		Secret:  []byte(a.config.Auth.JWTSecret),
+		APIKeys: apiKeyService,
+	})
+
+	limits, err := a.rateLimiters()
+	if err != nil {
+		return err
+	}
+
 	api := a.fiber.Group("/api")
 	api.Get("/health", healthHandler.Check)
 
-	v1 := api.Group("/v1")
+	authRoutes := api.Group("/v1/auth")
+	{
+		authRoutes.Post("/login", limits.auth, authHandler.Login)
+		authRoutes.Post("/refresh", limits.auth, authHandler.Refresh)
+		authRoutes.Post("/logout", limits.auth, authHandler.Logout)
+		authRoutes.Get("/me", limits.ip, authenticate, limits.api, authHandler.Me)
+	}
+
+	webhookRepo := repository.NewWebhookRepository(a.db)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo)
+	dispatcher := webhooks.NewDispatcher(webhookRepo, webhooks.Config{
+		Timeout:              a.config.Webhooks.Timeout,
+		MaxAttempts:          a.config.Webhooks.MaxAttempts,
+		AllowPrivateNetworks: a.config.Webhooks.AllowPrivateNetworks,
+	})
+	a.workers = append(a.workers, dispatcher.Run)
+
+	sinks, err := a.outboxSinks(webhooks.NewSink(webhookRepo))
+	if err != nil {
+		return err
+	}
+	outboxRepo := repository.NewOutboxRepository(a.db)
+	streamHandler := handlers.NewInvoiceStreamHandler(a.bus, outboxRepo)
+	relay := events.NewRelay(outboxRepo, sinks, events.RelayConfig{
+		Retention: a.config.Outbox.Retention,
+	})
+	a.workers = append(a.workers, relay.Run)
+	if slices.Contains(a.config.Outbox.Sinks, "bus") {
+		a.workers = append(a.workers, events.NewFeed(outboxRepo, a.bus).Run)
+	}
+
+	emailRepo := repository.NewInvoiceEmailRepository(a.db)
+	mailService, err := a.mailService(emailRepo)
+	if err != nil {
+		return err
+	}
+	emailHandler := handlers.NewInvoiceEmailHandler(repo, emailRepo, mailService)
+	if mailService != nil {
+		schedule, err := mailer.ParseSchedule(a.config.Mail.DunningSchedule)
+		if err != nil {
+			return fmt.Errorf("invalid mail.dunning_schedule: %w", err)
+		}
+		dunning := mailer.NewDunning(mailService, emailRepo, mailer.DunningConfig{
+			Interval: a.config.Mail.DunningInterval,
+			Schedule: schedule,
+		})
+		a.workers = append(a.workers, dunning.Run)
+	}
+
+	idempotencyRepo := repository.NewIdempotencyRepository(a.db)
+	a.workers = append(a.workers, func(ctx context.Context) {
+		purgeIdempotencyKeys(ctx, idempotencyRepo)
+	})
+
+	// Everything else under /api/v1 requires an access token or API key and
+	// acts on a single organization. Mutating requests may carry an
+	// Idempotency-Key so that retries are answered from the first response.
+	// The IP limit comes first, so that requests with invalid credentials
+	// are limited too.
+	v1 := api.Group("/v1", limits.ip, authenticate, middleware.Tenant(middleware.TenantConfig{
+		Policy: policy,
+		Lookup: orgRepo,
+	}), limits.api, middleware.Idempotency(middleware.IdempotencyConfig{
+		Store: idempotencyRepo,
+		TTL:   a.config.Idempotency.TTL,
+	}))
 	invoices := v1.Group("/invoices")
 	{
-		invoices.Get("/", invoiceHandler.GetInvoices)
-		invoices.Get("/:id", invoiceHandler.GetInvoiceByID)
-		invoices.Post("/", invoiceHandler.CreateInvoice)
-		invoices.Put("/:id", invoiceHandler.UpdateInvoice)
-		invoices.Delete("/:id", invoiceHandler.DeleteInvoice)
+		invoices.Get("/", policy.Require(middleware.PermInvoicesRead), middleware.CacheControl(a.config.Server.ListCacheControl), invoiceHandler.GetInvoices)
+		invoices.Get("/export", policy.Require(middleware.PermInvoicesExport), limits.heavy("export"), invoiceHandler.ExportInvoices)
+		invoices.Get("/stream", policy.Require(middleware.PermInvoicesRead), streamHandler.StreamInvoices)
+		invoices.Get("/:id", policy.Require(middleware.PermInvoicesRead), middleware.CacheControl(a.config.Server.ItemCacheControl), invoiceHandler.GetInvoiceByID)
+		invoices.Post("/", policy.Require(middleware.PermInvoicesWrite), invoiceHandler.CreateInvoice)
+		invoices.Post("/import", policy.Require(middleware.PermInvoicesImport), limits.heavy("import"), invoiceHandler.ImportInvoices)
+		invoices.Post("/batch", policy.Require(middleware.PermInvoicesWrite), limits.heavy("batch"), invoiceHandler.BatchInvoices)
+		invoices.Put("/:id", policy.Require(middleware.PermInvoicesWrite), invoiceHandler.UpdateInvoice)
+		invoices.Post("/:id/send", policy.Require(middleware.PermInvoicesSend), limits.heavy("send"), emailHandler.SendInvoice)
+		invoices.Get("/:id/emails", policy.Require(middleware.PermInvoicesRead), emailHandler.ListInvoiceEmails)
+		invoices.Delete("/:id", policy.Require(middleware.PermInvoicesDelete), invoiceHandler.DeleteInvoice)
+	}
+
+	reports := v1.Group("/reports", policy.Require(middleware.PermReportsRead), limits.heavy("reports"))
+	{
+		reports.Get("/summary", reportHandler.GetSummary)
+		reports.Get("/revenue", reportHandler.GetRevenue)
+	}
+
+	apiKeys := v1.Group("/api-keys", policy.Require(middleware.PermAPIKeysManage))
+	{
+		apiKeys.Get("/", apiKeyHandler.ListAPIKeys)
+		apiKeys.Post("/", apiKeyHandler.CreateAPIKey)
+		apiKeys.Delete("/:id", apiKeyHandler.RevokeAPIKey)
+	}
+
+	webhookRoutes := v1.Group("/webhooks", policy.Require(middleware.PermWebhooksManage))
+	{
+		webhookRoutes.Get("/", webhookHandler.ListWebhooks)
+		webhookRoutes.Post("/", webhookHandler.CreateWebhook)
+		webhookRoutes.Get("/:id", webhookHandler.GetWebhook)
+		webhookRoutes.Delete("/:id", webhookHandler.DeleteWebhook)
+		webhookRoutes.Get("/:id/deliveries", webhookHandler.ListDeliveries)
+		webhookRoutes.Post("/:id/deliveries/:deliveryId/retry", webhookHandler.RetryDelivery)
+	}
+
+	organizations := v1.Group("/organizations", policy.Require(middleware.PermOrganizationsManage))
+	{
+		organizations.Get("/", organizationHandler.ListOrganizations)
+		organizations.Post("/", organizationHandler.CreateOrganization)
 	}
 
 	return nil
 }
 
+// outboxSinks returns the sinks named in outbox.sinks, followed by the
+// metrics sink when metrics are enabled. The bus is not one of them: the
+// relay runs on a single instance, so each instance feeds its own bus from
+// the published events instead.
+func (a *App) outboxSinks(webhookSink events.Sink) ([]events.Sink, error) {
+	available := map[string]events.Sink{
+		"webhooks": webhookSink,
+		"bus":      nil,
+		"log":      events.NewLogSink(),
+	}
+
+	var sinks []events.Sink
+	for _, name := range a.config.Outbox.Sinks {
+		sink, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+		if sink != nil {
+			sinks = append(sinks, sink)
+		}
+	}
+
+	// The metrics sink comes last, so that events retried because another
+	// sink failed are not counted twice.
+	if a.config.Features.Metrics {
+		sinks = append(sinks, events.NewMetricsSink())
+	}
+
+	return sinks, nil
+}
+
+// mailService returns nil when mail.smtp_host is not set, which disables sending
+// invoices and payment reminders.
+func (a *App) mailService(emails repository.InvoiceEmailRepository) (*mailer.Service, error) {
+	if a.config.Mail.SMTPHost == "" {
+		return nil, nil
+	}
+
+	from, err := mail.ParseAddress(a.config.Mail.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail.from %q: %w", a.config.Mail.From, err)
+	}
+
+	locale, err := export.LookupLocale(a.config.Mail.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail.locale: %w", err)
+	}
+
+	sender, err := mailer.NewSMTPSender(mailer.SMTPConfig{
+		Host:     a.config.Mail.SMTPHost,
+		Port:     a.config.Mail.SMTPPort,
+		Username: a.config.Mail.SMTPUsername,
+		Password: a.config.Mail.SMTPPassword,
+		TLS:      a.config.Mail.SMTPTLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := mailer.LoadTemplates(a.config.Mail.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := from.Name
+	if issuer == "" {
+		issuer = from.Address
+	}
+
+	return mailer.NewService(sender, templates, emails, mailer.Config{
+		From:         from.String(),
+		Issuer:       issuer,
+		PaymentTerms: a.config.Mail.PaymentTerms,
+		Locale:       locale,
+	}), nil
+}
+
+type rateLimiters struct {
+	auth fiber.Handler
+	ip   fiber.Handler
+	api  fiber.Handler
+	// heavy returns the limiter of one group of expensive routes.
+	heavy func(group string) fiber.Handler
+}
+
+// rateLimiters builds one limiter per route group. Auth routes are limited
+// per IP, and so is every other route before its credentials are checked;
+// after that they are limited per API key or user. Export, import, batch,
+// send and reports are expensive and get an additional, stricter limit,
+// with a bucket per route group so that one cannot starve the others.
+func (a *App) rateLimiters() (*rateLimiters, error) {
+	store := middleware.NewMemoryRateLimitStore()
+
+	newLimiter := func(name, spec string, key func(c *fiber.Ctx) string) (fiber.Handler, error) {
+		limit, err := middleware.ParseLimit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s rate limit: %w", name, err)
+		}
+
+		return middleware.RateLimit(middleware.RateLimitConfig{
+			Name:    name,
+			Limit:   limit,
+			Store:   store,
+			KeyFunc: key,
+		}), nil
+	}
+
+	byIP := func(c *fiber.Ctx) string {
+		return "ip:" + c.IP()
+	}
+
+	auth, err := newLimiter("auth", a.config.RateLimit.Auth, byIP)
+	if err != nil {
+		return nil, err
+	}
+
+	ip, err := newLimiter("ip", a.config.RateLimit.IP, byIP)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := newLimiter("api", a.config.RateLimit.API, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	heavyLimit, err := middleware.ParseLimit(a.config.RateLimit.Heavy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid heavy rate limit: %w", err)
+	}
+	heavy := func(group string) fiber.Handler {
+		return middleware.RateLimit(middleware.RateLimitConfig{
+			Name:  "heavy:" + group,
+			Limit: heavyLimit,
+			Store: store,
+		})
+	}
+
+	return &rateLimiters{auth: auth, ip: ip, api: api, heavy: heavy}, nil
+}
+
+func purgeIdempotencyKeys(ctx context.Context, repo repository.IdempotencyRepository) {
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := repo.DeleteExpired(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "Failed to purge idempotency keys", "error", err)
+			}
+		}
+	}
+}
+
 func (a *App) setupSwagger() {
 	swaggerSpec := docs.GenerateSwaggerSpec()
 
@@ -111,36 +467,84 @@ func (a *App) setupSwagger() {
 		DeepLinking: true,
 	}))
 
-	fmt.Println("Swagger documentation is available at /swagger")
+	slog.Info("Swagger documentation is available at /swagger")
 }
 
 func (a *App) Start(port string) error {
 	signal.Notify(a.shutdown, syscall.SIGINT, syscall.SIGTERM)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopWorkers = cancel
+	for _, worker := range a.workers {
+		a.runningWorkers.Add(1)
+		go func() {
+			defer a.runningWorkers.Done()
+			worker(ctx)
+		}()
+	}
+
 	go func() {
-		fmt.Printf("Server started on port %s\n", port)
+		slog.Info("Server started", "port", port)
 		if err := a.fiber.Listen(fmt.Sprintf(":%s", port)); err != nil {
-			fmt.Printf("Server error: %v\n", err)
+			slog.Error("Server stopped", "error", err)
 		}
 	}()
 
 	return nil
 }
 
+// waitForWorkers waits until the workers have returned or ctx is done.
+func (a *App) waitForWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.runningWorkers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background workers did not stop in time: %w", ctx.Err())
+	}
+}
+
 func (a *App) WaitForShutdown() <-chan os.Signal {
 	return a.shutdown
 }
 
 func (a *App) Shutdown(ctx context.Context) error {
-	fmt.Println("Starting graceful shutdown...")
+	slog.Info("Starting graceful shutdown")
 
-	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	ctx, cancel := context.WithTimeout(ctx, a.config.Server.ShutdownTimeout)
 	defer cancel()
 
+	if a.stopWorkers != nil {
+		a.stopWorkers()
+	}
+
+	// Open event streams end when the bus closes; otherwise the server
+	// would wait for them until the timeout.
+	a.bus.Close()
+
 	if err := a.fiber.ShutdownWithContext(ctx); err != nil {
 		return fmt.Errorf("error during server shutdown: %w", err)
 	}
 
-	fmt.Println("Server shutdown completed successfully")
+	// Workers finish what they are doing first, e.g. recording a reminder
+	// that was just sent, so that it is not sent again after a restart.
+	workersErr := a.waitForWorkers(ctx)
+
+	if a.closeCache != nil {
+		if err := a.closeCache(); err != nil {
+			slog.Error("Failed to close cache", "error", err)
+		}
+	}
+
+	if workersErr != nil {
+		return workersErr
+	}
+
+	slog.Info("Server shutdown completed")
 	return nil
 }
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/cache"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix keeps the keys of this service apart from other users of a
+// shared Redis.
+const redisKeyPrefix = "invoices-api:"
+
+// NewCache builds the configured cache backend. The returned close function
+// releases its connections and is never nil. An unreachable Redis is only
+// logged, as every cache error degrades to a miss.
+func NewCache(cfg config.CacheConfig) (cache.Cache, func() error, error) {
+	noClose := func() error { return nil }
+
+	if !cfg.Enabled {
+		return cache.Nop{}, noClose, nil
+	}
+
+	switch cfg.Backend {
+	case "memory":
+		return cache.NewMemory(cfg.MaxEntries), noClose, nil
+
+	case "redis":
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cache.redis_url: %w", err)
+		}
+
+		client := redis.NewClient(options)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			slog.Warn("Redis cache is unreachable", "addr", options.Addr, "error", err)
+		}
+
+		redisCache := cache.NewRedis(client, redisKeyPrefix)
+		return redisCache, redisCache.Close, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/events"
+	"invoices-api/internal/mailer"
+	"invoices-api/pkg/database"
+	"invoices-api/pkg/middleware"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CheckConfig validates the settings that config.Validate leaves to the
+// packages using them and that New would otherwise only reject at startup,
+// without touching the database.
+func CheckConfig(cfg *config.Config) error {
+	a := &App{config: cfg, bus: events.NewBus()}
+	defer a.bus.Close()
+
+	var errs []error
+	if _, err := middleware.LoadPolicy(cfg.Auth.RBACPolicyFile); err != nil {
+		errs = append(errs, fmt.Errorf("auth.rbac_policy_file: %w", err))
+	}
+	if _, err := a.rateLimiters(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := a.outboxSinks(events.NewLogSink()); err != nil {
+		errs = append(errs, fmt.Errorf("outbox.sinks: %w", err))
+	}
+	if _, err := a.mailService(nil); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := mailer.ParseSchedule(cfg.Mail.DunningSchedule); err != nil {
+		errs = append(errs, fmt.Errorf("mail.dunning_schedule: %w", err))
+	}
+	if cfg.Cache.Enabled && cfg.Cache.Backend == "redis" {
+		if _, err := redis.ParseURL(cfg.Cache.RedisURL); err != nil {
+			errs = append(errs, fmt.Errorf("cache.redis_url: %w", err))
+		}
+	}
+	if cfg.Database.SeedProfile != "" {
+		if _, err := database.LoadProfile(cfg.Database.SeedProfile); err != nil {
+			errs = append(errs, fmt.Errorf("database.seed_profile: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
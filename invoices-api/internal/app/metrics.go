@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"invoices-api/internal/cache"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/database"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// invoiceMetricsInterval limits how often a scrape recounts the invoices;
+// scrapes in between report the previous counts.
+const invoiceMetricsInterval = time.Minute
+
+// registerMetrics exports the database pool, the in-memory cache and the
+// invoice totals next to the HTTP metrics.
+func (a *App) registerMetrics(reports repository.ReportRepository) error {
+	if !a.config.Features.Metrics {
+		return nil
+	}
+
+	if err := database.RegisterPoolMetrics(a.db, prometheus.DefaultRegisterer); err != nil {
+		return err
+	}
+
+	if memory, ok := a.cache.(*cache.Memory); ok {
+		if err := cache.RegisterMemoryMetrics(memory, prometheus.DefaultRegisterer); err != nil {
+			return err
+		}
+	}
+
+	return prometheus.Register(newInvoiceCollector(reports, a.config.Mail.PaymentTerms))
+}
+
+// invoiceCollector reports the number and amount of invoices, and of overdue
+// invoices, by status. The counts come from the database and are the same on
+// every instance.
+type invoiceCollector struct {
+	reports repository.ReportRepository
+	terms   time.Duration
+
+	count         *prometheus.Desc
+	amount        *prometheus.Desc
+	overdueCount  *prometheus.Desc
+	overdueAmount *prometheus.Desc
+
+	mu      sync.Mutex
+	totals  []models.StatusTotals
+	updated time.Time
+}
+
+func newInvoiceCollector(reports repository.ReportRepository, terms time.Duration) *invoiceCollector {
+	status := []string{"status"}
+	return &invoiceCollector{
+		reports:       reports,
+		terms:         terms,
+		count:         prometheus.NewDesc("invoices", "Invoices by status", status, nil),
+		amount:        prometheus.NewDesc("invoices_amount", "Sum of the invoice amounts by status", status, nil),
+		overdueCount:  prometheus.NewDesc("invoices_overdue", "Unpaid invoices past their due date by status", status, nil),
+		overdueAmount: prometheus.NewDesc("invoices_overdue_amount", "Sum of the amounts of overdue invoices by status", status, nil),
+	}
+}
+
+func (c *invoiceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+	ch <- c.amount
+	ch <- c.overdueCount
+	ch <- c.overdueAmount
+}
+
+func (c *invoiceCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, totals := range c.load() {
+		ch <- prometheus.MustNewConstMetric(c.count, prometheus.GaugeValue, float64(totals.Count), totals.Status)
+		ch <- prometheus.MustNewConstMetric(c.amount, prometheus.GaugeValue, totals.Amount, totals.Status)
+		ch <- prometheus.MustNewConstMetric(c.overdueCount, prometheus.GaugeValue, float64(totals.OverdueCount), totals.Status)
+		ch <- prometheus.MustNewConstMetric(c.overdueAmount, prometheus.GaugeValue, totals.OverdueAmount, totals.Status)
+	}
+}
+
+// load returns the totals, recounting them when they are older than
+// invoiceMetricsInterval. A failed count keeps the previous totals.
+func (c *invoiceCollector) load() []models.StatusTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.updated) < invoiceMetricsInterval {
+		return c.totals
+	}
+
+	totals, err := c.reports.StatusTotals(context.Background(), now, c.terms)
+	if err != nil {
+		slog.Warn("Failed to count invoices for metrics", "error", err)
+		return c.totals
+	}
+
+	c.totals, c.updated = totals, now
+	return totals
+}
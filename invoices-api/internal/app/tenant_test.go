@@ -0,0 +1,282 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"invoices-api/config"
+	"invoices-api/internal/auth"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/database/dbtest"
+	"invoices-api/pkg/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// client sends requests to the app as one user.
+type client struct {
+	t      *testing.T
+	app    *App
+	token  string
+	header map[string]string
+}
+
+type response struct {
+	status int
+	body   []byte
+}
+
+func (r response) decode(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.body, v); err != nil {
+		t.Fatalf("failed to decode %s: %v", r.body, err)
+	}
+}
+
+func (c *client) do(method, path string, body any) response {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for key, value := range c.header {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.app.fiber.Test(req, -1)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return response{status: resp.StatusCode, body: data}
+}
+
+// expect fails the test unless the request gets status.
+func (c *client) expect(status int, method, path string, body any) response {
+	c.t.Helper()
+
+	resp := c.do(method, path, body)
+	if resp.status != status {
+		c.t.Fatalf("%s %s: got %d %s, want %d", method, path, resp.status, resp.body, status)
+	}
+	return resp
+}
+
+func newTestApp(t *testing.T) (*App, *auth.Service) {
+	t.Helper()
+
+	db := dbtest.Open(t)
+
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "test-secret"
+	cfg.RateLimit = config.RateLimitConfig{Auth: "off", IP: "off", API: "off", Heavy: "off"}
+	cfg.Logging.AccessLog = false
+	cfg.Features = config.FeaturesConfig{}
+
+	a, err := New(db, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.closeCache() })
+
+	return a, auth.NewService(repository.NewUserRepository(db), auth.Config{})
+}
+
+// login creates a user with role in org and returns a client for it.
+func login(t *testing.T, a *App, users *auth.Service, org *models.Organization, role string) *client {
+	t.Helper()
+
+	email := fmt.Sprintf("%s@%s.example", role, org.Slug)
+	if _, err := users.CreateUser(context.Background(), org.ID, email, "password123", role, role); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &client{t: t, app: a}
+	var tokens struct {
+		Data models.TokenResponse `json:"data"`
+	}
+	c.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", models.LoginRequest{Email: email, Password: "password123"}).
+		decode(t, &tokens)
+	c.token = tokens.Data.AccessToken
+	return c
+}
+
+func (c *client) createInvoice(service string) uint {
+	c.t.Helper()
+
+	var created struct {
+		Data models.Invoice `json:"data"`
+	}
+	c.expect(http.StatusCreated, http.MethodPost, "/api/v1/invoices", map[string]any{
+		"service_name":   service,
+		"invoice_number": 1,
+		"date":           "2024-05-01T00:00:00Z",
+		"amount":         100,
+		"status":         "Pending",
+	}).decode(c.t, &created)
+	return created.Data.ID
+}
+
+func TestTenantIsolationHTTP(t *testing.T) {
+	a, users := newTestApp(t)
+	acmeOrg := dbtest.Organization(t, a.db, "acme")
+	globexOrg := dbtest.Organization(t, a.db, "globex")
+
+	acme := login(t, a, users, acmeOrg, middleware.RoleAdmin)
+	globex := login(t, a, users, globexOrg, middleware.RoleAdmin)
+
+	acmeInvoice := acme.createInvoice("Acme consulting")
+	globexInvoice := globex.createInvoice("Globex hosting")
+	invoicePath := fmt.Sprintf("/api/v1/invoices/%d", acmeInvoice)
+
+	t.Run("invoices", func(t *testing.T) {
+		globex.expect(http.StatusNotFound, http.MethodGet, invoicePath, nil)
+		globex.expect(http.StatusNotFound, http.MethodGet, invoicePath+"/emails", nil)
+		globex.expect(http.StatusNotFound, http.MethodPut, invoicePath, map[string]any{
+			"service_name":   "Taken over",
+			"invoice_number": 1,
+			"date":           "2024-05-01T00:00:00Z",
+			"amount":         1,
+			"status":         "Pending",
+		})
+		globex.expect(http.StatusNotFound, http.MethodDelete, invoicePath, nil)
+		acme.expect(http.StatusOK, http.MethodGet, invoicePath, nil)
+	})
+
+	t.Run("listings", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/invoices", "/api/v1/invoices?search=Acme"} {
+			var list models.InvoiceListResponse
+			globex.expect(http.StatusOK, http.MethodGet, path, nil).decode(t, &list)
+			for _, invoice := range list.Data {
+				if invoice.ID != globexInvoice {
+					t.Fatalf("%s listed invoice %d of another organization", path, invoice.ID)
+				}
+			}
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		resp := globex.expect(http.StatusOK, http.MethodGet, "/api/v1/invoices/export?format=csv", nil)
+		if strings.Contains(string(resp.body), "Acme") {
+			t.Fatalf("export contains another organization's invoice:\n%s", resp.body)
+		}
+		if !strings.Contains(string(resp.body), "Globex") {
+			t.Fatalf("export is missing the organization's own invoice:\n%s", resp.body)
+		}
+	})
+
+	t.Run("reports", func(t *testing.T) {
+		var summary struct {
+			Data models.ReportSummary `json:"data"`
+		}
+		globex.expect(http.StatusOK, http.MethodGet, "/api/v1/reports/summary", nil).decode(t, &summary)
+		if summary.Data.Totals.Count != 1 {
+			t.Fatalf("summary counts %d invoices, want 1", summary.Data.Totals.Count)
+		}
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		var created struct {
+			Data models.WebhookSubscription `json:"data"`
+		}
+		acme.expect(http.StatusCreated, http.MethodPost, "/api/v1/webhooks", map[string]any{
+			"url":    "https://hooks.example.com/invoices",
+			"events": []string{"*"},
+		}).decode(t, &created)
+		path := fmt.Sprintf("/api/v1/webhooks/%d", created.Data.ID)
+
+		var list struct {
+			Data []models.WebhookSubscription `json:"data"`
+		}
+		globex.expect(http.StatusOK, http.MethodGet, "/api/v1/webhooks", nil).decode(t, &list)
+		if len(list.Data) != 0 {
+			t.Fatalf("listed webhooks of another organization: %v", list.Data)
+		}
+		globex.expect(http.StatusNotFound, http.MethodGet, path, nil)
+		globex.expect(http.StatusNotFound, http.MethodGet, path+"/deliveries", nil)
+		globex.expect(http.StatusNotFound, http.MethodDelete, path, nil)
+		acme.expect(http.StatusOK, http.MethodGet, path, nil)
+	})
+
+	t.Run("api keys", func(t *testing.T) {
+		var created struct {
+			Data models.CreatedAPIKey `json:"data"`
+		}
+		acme.expect(http.StatusCreated, http.MethodPost, "/api/v1/api-keys", map[string]any{
+			"name":   "cron",
+			"scopes": []string{"invoices:read"},
+		}).decode(t, &created)
+
+		var list struct {
+			Data []models.APIKey `json:"data"`
+		}
+		globex.expect(http.StatusOK, http.MethodGet, "/api/v1/api-keys", nil).decode(t, &list)
+		if len(list.Data) != 0 {
+			t.Fatalf("listed API keys of another organization: %v", list.Data)
+		}
+		globex.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/api/v1/api-keys/%d", created.Data.ID), nil)
+
+		key := &client{t: t, app: a, token: created.Data.Key}
+		key.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/api/v1/invoices/%d", globexInvoice), nil)
+		key.header = map[string]string{middleware.HeaderTenantID: fmt.Sprint(globexOrg.ID)}
+		key.expect(http.StatusForbidden, http.MethodGet, "/api/v1/invoices", nil)
+	})
+}
+
+func TestTenantSwitch(t *testing.T) {
+	a, users := newTestApp(t)
+	acmeOrg := dbtest.Organization(t, a.db, "acme")
+	globexOrg := dbtest.Organization(t, a.db, "globex")
+
+	globex := login(t, a, users, globexOrg, middleware.RoleAdmin)
+	globexInvoice := globex.createInvoice("Globex hosting")
+
+	// An organization's admin holds "*" but not tenants:switch.
+	acme := login(t, a, users, acmeOrg, middleware.RoleAdmin)
+	acme.header = map[string]string{middleware.HeaderTenantID: fmt.Sprint(globexOrg.ID)}
+	acme.expect(http.StatusForbidden, http.MethodGet, "/api/v1/invoices", nil)
+	acme.expect(http.StatusForbidden, http.MethodGet, fmt.Sprintf("/api/v1/invoices/%d", globexInvoice), nil)
+	acme.header = nil
+	acme.expect(http.StatusForbidden, http.MethodGet, "/api/v1/organizations", nil)
+
+	// Naming one's own organization is not a switch.
+	acme.header = map[string]string{middleware.HeaderTenantID: fmt.Sprint(acmeOrg.ID)}
+	acme.expect(http.StatusOK, http.MethodGet, "/api/v1/invoices", nil)
+
+	defaultOrg, err := repository.NewOrganizationRepository(a.db).GetBySlug(context.Background(), models.DefaultOrganizationSlug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform := login(t, a, users, defaultOrg, middleware.RolePlatformAdmin)
+	platform.header = map[string]string{middleware.HeaderTenantID: fmt.Sprint(globexOrg.ID)}
+
+	var list models.InvoiceListResponse
+	platform.expect(http.StatusOK, http.MethodGet, "/api/v1/invoices", nil).decode(t, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != globexInvoice {
+		t.Fatalf("got %v, want the invoice of the selected organization", list.Data)
+	}
+
+	platform.header = map[string]string{middleware.HeaderTenantID: "999999"}
+	platform.expect(http.StatusNotFound, http.MethodGet, "/api/v1/invoices", nil)
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// lastUsedInterval bounds how often last_used_at is written for a key.
+	lastUsedInterval = time.Minute
+
+	maxAPIKeyNameLength = 100
+)
+
+type APIKeyService struct {
+	keys   repository.APIKeyRepository
+	users  repository.UserRepository
+	policy *middleware.Policy
+}
+
+func NewAPIKeyService(keys repository.APIKeyRepository, users repository.UserRepository, policy *middleware.Policy) *APIKeyService {
+	return &APIKeyService{
+		keys:   keys,
+		users:  users,
+		policy: policy,
+	}
+}
+
+// Create issues a key for the principal. A key can only be granted scopes
+// its owner currently holds.
+func (s *APIKeyService) Create(ctx context.Context, owner *middleware.Principal, req models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		return nil, middleware.NewBadRequestError(fmt.Sprintf("Name is required and must be at most %d characters", maxAPIKeyNameLength))
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, middleware.NewBadRequestError("At least one scope is required")
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		permission := middleware.Permission(strings.TrimSpace(scope))
+		if !middleware.IsValidScope(permission) {
+			return nil, middleware.NewBadRequestError(fmt.Sprintf("Invalid scope %q", scope))
+		}
+		if !s.policy.Can(owner, permission) {
+			return nil, middleware.NewForbiddenError(fmt.Sprintf("Cannot grant scope %q you do not hold", scope))
+		}
+		if !seen[string(permission)] {
+			seen[string(permission)] = true
+			scopes = append(scopes, string(permission))
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, middleware.NewBadRequestError("Expiry must be in the future")
+	}
+
+	prefix, secret, err := newAPIKey()
+	if err != nil {
+		return nil, middleware.NewInternalError("Failed to generate API key")
+	}
+	key := prefix + "_" + secret
+
+	record := models.APIKey{
+		TenantID:  owner.TenantID,
+		UserID:    owner.UserID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.keys.Create(ctx, &record); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: record, Key: key}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, owner *middleware.Principal) ([]models.APIKey, error) {
+	return s.keys.ListByUser(ctx, owner.UserID)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, owner *middleware.Principal, id uint) error {
+	return s.keys.Revoke(ctx, id, owner.UserID)
+}
+
+// Rotate replaces each active key of the owner with a new key of the same
+// name, scopes and expiry, then revokes the old one. Keys with scopes the
+// owner no longer holds are not rotated and are reported in the error.
+func (s *APIKeyService) Rotate(ctx context.Context, owner *middleware.Principal) ([]models.CreatedAPIKey, error) {
+	keys, err := s.keys.ListByUser(ctx, owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var rotated []models.CreatedAPIKey
+	var errs []error
+	for _, key := range keys {
+		if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+			continue
+		}
+
+		created, err := s.Create(ctx, owner, models.CreateAPIKeyRequest{
+			Name:      key.Name,
+			Scopes:    key.Scopes,
+			ExpiresAt: key.ExpiresAt,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s (%s): %w", key.Prefix, key.Name, err))
+			continue
+		}
+		rotated = append(rotated, *created)
+
+		if err := s.keys.Revoke(ctx, key.ID, owner.UserID); err != nil {
+			errs = append(errs, fmt.Errorf("key %s (%s) was replaced but not revoked: %w", key.Prefix, key.Name, err))
+		}
+	}
+
+	return rotated, errors.Join(errs...)
+}
+
+// ResolveAPIKey implements middleware.APIKeyResolver.
+func (s *APIKeyService) ResolveAPIKey(ctx context.Context, key string) (*middleware.Principal, error) {
+	if !strings.HasPrefix(key, middleware.APIKeyPrefix) {
+		return nil, middleware.NewUnauthorizedError("Invalid API key")
+	}
+
+	record, err := s.keys.GetByHash(ctx, hashToken(key))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, middleware.NewUnauthorizedError("Invalid API key")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil || (record.ExpiresAt != nil && now.After(*record.ExpiresAt)) {
+		return nil, middleware.NewUnauthorizedError("API key is revoked or expired")
+	}
+
+	user, err := s.users.GetByID(ctx, record.UserID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, middleware.NewUnauthorizedError("Invalid API key")
+		}
+		return nil, err
+	}
+	if !user.Active || user.TenantID != record.TenantID {
+		return nil, middleware.NewUnauthorizedError("API key owner is disabled")
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) > lastUsedInterval {
+		if err := s.keys.TouchLastUsed(ctx, record.ID, now, lastUsedInterval); err != nil {
+			slog.WarnContext(ctx, "Failed to record API key use", "api_key_id", record.ID, "error", err)
+		}
+	}
+
+	scopes := make([]middleware.Permission, len(record.Scopes))
+	for i, scope := range record.Scopes {
+		scopes[i] = middleware.Permission(scope)
+	}
+
+	return &middleware.Principal{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Role:     user.Role,
+		TenantID: record.TenantID,
+		APIKeyID: record.ID,
+		Scopes:   scopes,
+	}, nil
+}
+
+// newAPIKey returns the public prefix and the secret part of a new key. The
+// full key has the form inv_<8 hex chars>_<43 base64url chars>.
+func newAPIKey() (string, string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	return middleware.APIKeyPrefix + hex.EncodeToString(id), base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordCost      = 12
+	MinPasswordLength = 8
+)
+
+// dummyHash is compared against when the email is unknown so that a failed
+// login takes the same time whether or not the account exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("invoices-api-dummy-password"), passwordCost)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrNoAdminCredentials is returned by EnsureAdmin when there is no admin
+// and no bootstrap credentials were configured.
+var ErrNoAdminCredentials = errors.New("no admin user exists and ADMIN_EMAIL/ADMIN_PASSWORD are not set")
+
+type Config struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type Service struct {
+	users  repository.UserRepository
+	config Config
+}
+
+func NewService(users repository.UserRepository, config Config) *Service {
+	return &Service{
+		users:  users,
+		config: config,
+	}
+}
+
+func (s *Service) Login(ctx context.Context, email, password string) (*models.TokenResponse, error) {
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if isNotFound(err) {
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return nil, middleware.NewUnauthorizedError("Invalid email or password")
+		}
+		return nil, err
+	}
+
+	if !CheckPassword(user.PasswordHash, password) || !user.Active {
+		return nil, middleware.NewUnauthorizedError("Invalid email or password")
+	}
+
+	if err := s.users.TouchLastLogin(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Refresh tokens are
+// rotated on every use; presenting one that was already used revokes every
+// session of the user, since it means the token has leaked.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	stored, err := s.users.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, middleware.NewUnauthorizedError("Invalid refresh token")
+		}
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		if err := s.users.RevokeAllRefreshTokens(ctx, stored.UserID); err != nil {
+			return nil, err
+		}
+		return nil, middleware.NewUnauthorizedError("Refresh token has already been used")
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, middleware.NewUnauthorizedError("Refresh token has expired")
+	}
+
+	revoked, err := s.users.RevokeRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		// Lost a race with a concurrent refresh of the same token.
+		return nil, middleware.NewUnauthorizedError("Refresh token has already been used")
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if err != nil || user.TenantID != stored.TenantID {
+		// A session does not follow a user into another organization.
+		return nil, middleware.NewUnauthorizedError("Invalid refresh token")
+	}
+	if !user.Active {
+		return nil, middleware.NewUnauthorizedError("User is disabled")
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.users.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	_, err = s.users.RevokeRefreshToken(ctx, stored.ID)
+	return err
+}
+
+// EnsureAdmin makes sure at least one platform admin exists so that a fresh
+// deployment can be logged into and organizations can be created. An
+// existing user with the configured email is promoted instead of being
+// recreated; a new one joins tenantID.
+func (s *Service) EnsureAdmin(ctx context.Context, tenantID uint, email, password, name string) error {
+	admins, err := s.users.CountByRole(ctx, middleware.RolePlatformAdmin)
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return nil
+	}
+
+	email = strings.TrimSpace(email)
+	if email == "" || password == "" {
+		return ErrNoAdminCredentials
+	}
+
+	existing, err := s.users.GetByEmail(ctx, email)
+	if err == nil {
+		return s.users.SetRole(ctx, existing.ID, middleware.RolePlatformAdmin)
+	}
+	if !isNotFound(err) {
+		return err
+	}
+
+	_, err = s.CreateUser(ctx, tenantID, email, password, name, middleware.RolePlatformAdmin)
+	return err
+}
+
+// CreateUser adds an active user with the given role to tenantID. The role
+// is not checked against the policy; callers do that.
+func (s *Service) CreateUser(ctx context.Context, tenantID uint, email, password, name, role string) (*models.User, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, middleware.NewBadRequestError("Email is required")
+	}
+	if len(password) < MinPasswordLength {
+		return nil, middleware.NewBadRequestError(fmt.Sprintf("Password must be at least %d characters", MinPasswordLength))
+	}
+
+	if _, err := s.users.GetByEmail(ctx, email); err == nil {
+		return nil, middleware.NewError(http.StatusConflict, "A user with this email already exists")
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		TenantID:     tenantID,
+		Email:        email,
+		Name:         strings.TrimSpace(name),
+		PasswordHash: hash,
+		Role:         role,
+		Active:       true,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RevokeSessions revokes every refresh token of a user. Access tokens
+// already issued stay valid until they expire.
+func (s *Service) RevokeSessions(ctx context.Context, userID uint) error {
+	return s.users.RevokeAllRefreshTokens(ctx, userID)
+}
+
+func (s *Service) issueTokens(ctx context.Context, user *models.User) (*models.TokenResponse, error) {
+	now := time.Now()
+
+	claims := middleware.AccessClaims{
+		Email:    user.Email,
+		Role:     user.Role,
+		TenantID: user.TenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    middleware.TokenIssuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL)),
+		},
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.Secret)
+	if err != nil {
+		return nil, middleware.NewInternalError("Failed to sign access token")
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, middleware.NewInternalError("Failed to generate refresh token")
+	}
+
+	if err := s.users.CreateRefreshToken(ctx, &models.RefreshToken{
+		TenantID:  user.TenantID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.config.RefreshTokenTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*middleware.ErrorResponse)
+	return ok && e.Code == http.StatusNotFound
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// Cache stores values under keys for a limited time. Tags group keys, so that
+// a write can invalidate every entry derived from the data it changed
+// without knowing their keys.
+//
+// Implementations must be safe for concurrent use. Callers treat errors as
+// misses: a broken cache makes requests slower, never fail.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	Delete(ctx context.Context, keys ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+// Nop is a cache that stores nothing. It is used when caching is disabled.
+type Nop struct{}
+
+func (Nop) Get(context.Context, string) ([]byte, bool, error) { return nil, false, nil }
+
+func (Nop) Set(context.Context, string, []byte, time.Duration, ...string) error { return nil }
+
+func (Nop) Delete(context.Context, ...string) error { return nil }
+
+func (Nop) InvalidateTags(context.Context, ...string) error { return nil }
+
+// GetJSON decodes the value stored under key into v and reports whether it
+// was found. Errors are logged and reported as a miss.
+func GetJSON(ctx context.Context, c Cache, key string, v any) bool {
+	data, ok, err := c.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache get failed", "key", key, "error", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		slog.WarnContext(ctx, "Cache entry is corrupt", "key", key, "error", err)
+		return false
+	}
+	return true
+}
+
+// SetJSON stores v encoded as JSON. Errors are logged.
+func SetJSON(ctx context.Context, c Cache, key string, v any, ttl time.Duration, tags ...string) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache set failed", "key", key, "error", err)
+		return
+	}
+
+	if err := c.Set(ctx, key, data, ttl, tags...); err != nil {
+		slog.WarnContext(ctx, "Cache set failed", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries bounds a memory cache created with a non-positive size.
+const DefaultMaxEntries = 10000
+
+// Memory is an in-process LRU cache. Entries expire after their TTL, and the
+// least recently used entry is evicted when the cache is full. Each instance
+// of the API has its own, so it is only invalidated by writes made through
+// the same process; the TTL bounds how stale other instances can be.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	// order holds *memoryEntry values, most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{}
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+func NewMemory(maxEntries int) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &Memory{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+		tags:       map[string]map[string]struct{}{},
+		now:        time.Now,
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !m.now().Before(entry.expiresAt) {
+		m.remove(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+
+	entry := &memoryEntry{
+		key:       key,
+		value:     append([]byte(nil), value...),
+		expiresAt: m.now().Add(ttl),
+		tags:      tags,
+	}
+	m.entries[key] = m.order.PushFront(entry)
+	for _, tag := range tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = map[string]struct{}{}
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := m.entries[key]; ok {
+			m.remove(element)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) InvalidateTags(_ context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if element, ok := m.entries[key]; ok {
+				m.remove(element)
+			}
+		}
+		delete(m.tags, tag)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// remove must be called with mu held.
+func (m *Memory) remove(element *list.Element) {
+	entry := m.order.Remove(element).(*memoryEntry)
+	delete(m.entries, entry.key)
+
+	for _, tag := range entry.tags {
+		delete(m.tags[tag], entry.key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source for the memory cache.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestMemory(maxEntries int) (*Memory, *clock) {
+	c := &clock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMemory(maxEntries)
+	m.now = c.Now
+	return m, c
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	m, _ := newTestMemory(2)
+	ctx := context.Background()
+
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	m.Set(ctx, "b", []byte("2"), time.Minute)
+	// Reading a makes b the least recently used entry.
+	if _, ok, _ := m.Get(ctx, "a"); !ok {
+		t.Fatal("a is missing")
+	}
+	m.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := m.Get(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+}
+
+func TestMemoryExpiresEntries(t *testing.T) {
+	m, c := newTestMemory(10)
+	ctx := context.Background()
+
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	m.Set(ctx, "b", []byte("2"), 0)
+
+	c.now = c.now.Add(59 * time.Second)
+	if value, ok, _ := m.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Fatalf("Get(a) = %q, %t before the TTL", value, ok)
+	}
+	c.now = c.now.Add(time.Second)
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("a is still there after the TTL")
+	}
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Error("an entry without TTL was stored")
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", m.Len())
+	}
+}
+
+func TestMemoryCopiesValues(t *testing.T) {
+	m, _ := newTestMemory(10)
+	ctx := context.Background()
+
+	value := []byte("abc")
+	m.Set(ctx, "a", value, time.Minute)
+	value[0] = 'x'
+
+	got, _, _ := m.Get(ctx, "a")
+	got[1] = 'x'
+	if again, _, _ := m.Get(ctx, "a"); string(again) != "abc" {
+		t.Errorf("stored value changed to %q", again)
+	}
+}
+
+func TestMemoryInvalidatesTags(t *testing.T) {
+	m, _ := newTestMemory(10)
+	ctx := context.Background()
+
+	m.Set(ctx, "list:1", []byte("1"), time.Minute, "tenant:1")
+	m.Set(ctx, "list:2", []byte("2"), time.Minute, "tenant:1", "search")
+	m.Set(ctx, "list:3", []byte("3"), time.Minute, "tenant:2")
+
+	m.InvalidateTags(ctx, "tenant:1")
+
+	for key, want := range map[string]bool{"list:1": false, "list:2": false, "list:3": true} {
+		if _, ok, _ := m.Get(ctx, key); ok != want {
+			t.Errorf("%s present = %t, want %t", key, ok, want)
+		}
+	}
+	// Removing list:2 also dropped it from its other tag.
+	if len(m.tags) != 1 || m.tags["tenant:2"] == nil {
+		t.Errorf("tags = %v, want only tenant:2", m.tags)
+	}
+
+	// A key set again without its tag is not invalidated with it.
+	m.Set(ctx, "list:3", []byte("3"), time.Minute, "tenant:2")
+	m.Set(ctx, "list:3", []byte("3"), time.Minute)
+	m.InvalidateTags(ctx, "tenant:2")
+	if _, ok, _ := m.Get(ctx, "list:3"); !ok {
+		t.Error("list:3 was invalidated with a tag it no longer has")
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	m, _ := newTestMemory(10)
+	ctx := context.Background()
+
+	m.Set(ctx, "a", []byte("1"), time.Minute, "tag")
+	m.Set(ctx, "b", []byte("2"), time.Minute)
+	m.Delete(ctx, "a", "missing")
+
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("a was not deleted")
+	}
+	if _, ok, _ := m.Get(ctx, "b"); !ok {
+		t.Error("b was deleted")
+	}
+	if len(m.tags) != 0 {
+		t.Errorf("tags = %v, want none", m.tags)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var cacheRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Cache lookups by cache and result (hit, miss or error)",
+	},
+	[]string{"cache", "result"},
+)
+
+var cacheInvalidationsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_invalidations_total",
+		Help: "Keys and tags invalidated, by cache",
+	},
+	[]string{"cache"},
+)
+
+type instrumented struct {
+	Cache
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	errors        prometheus.Counter
+	invalidations prometheus.Counter
+}
+
+// Instrument counts the lookups and invalidations made through c under the
+// given cache name.
+func Instrument(c Cache, name string) Cache {
+	return &instrumented{
+		Cache:  c,
+		hits:   cacheRequestsTotal.WithLabelValues(name, "hit"),
+		misses: cacheRequestsTotal.WithLabelValues(name, "miss"),
+		errors: cacheRequestsTotal.WithLabelValues(name, "error"),
+
+		invalidations: cacheInvalidationsTotal.WithLabelValues(name),
+	}
+}
+
+func (i *instrumented) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := i.Cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		i.errors.Inc()
+	case ok:
+		i.hits.Inc()
+	default:
+		i.misses.Inc()
+	}
+	return value, ok, err
+}
+
+func (i *instrumented) Delete(ctx context.Context, keys ...string) error {
+	i.invalidations.Add(float64(len(keys)))
+	return i.Cache.Delete(ctx, keys...)
+}
+
+func (i *instrumented) InvalidateTags(ctx context.Context, tags ...string) error {
+	i.invalidations.Add(float64(len(tags)))
+	return i.Cache.InvalidateTags(ctx, tags...)
+}
+
+// RegisterMemoryMetrics exports the number of entries held by m.
+func RegisterMemoryMetrics(m *Memory, registerer prometheus.Registerer) error {
+	return registerer.Register(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "cache_entries",
+			Help: "Number of entries in the in-memory cache",
+		},
+		func() float64 { return float64(m.Len()) },
+	))
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis stores entries in Redis or a server speaking its protocol, so that
+// every instance of the API shares them and sees the same invalidations.
+//
+// A tag is a set holding the keys tagged with it. It expires with the most
+// recently added key, which is enough as long as a tag's keys share a TTL.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis returns a cache storing its keys under prefix. Any
+// redis.UniversalClient works, including one connected to an in-process
+// fake such as miniredis.
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.prefix+key, value, ttl)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, r.tagKey(tag), r.prefix+key)
+			pipe.PExpire(ctx, r.tagKey(tag), ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+// InvalidateTags reads and removes each tag set in one transaction, so that
+// keys tagged concurrently end up in a new set instead of being lost.
+func (r *Redis) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		var members *redis.StringSliceCmd
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			members = pipe.SMembers(ctx, r.tagKey(tag))
+			pipe.Del(ctx, r.tagKey(tag))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if keys := members.Val(); len(keys) > 0 {
+			if err := r.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func (r *Redis) tagKey(tag string) string {
+	return r.prefix + "tag:" + tag
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	r := NewRedis(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+	t.Cleanup(func() { r.Close() })
+	return r, server
+}
+
+func TestRedisGetSet(t *testing.T) {
+	r, server := newTestRedis(t)
+	ctx := context.Background()
+
+	if _, ok, err := r.Get(ctx, "a"); ok || err != nil {
+		t.Fatalf("Get on an empty cache = %t, %v", ok, err)
+	}
+
+	if err := r.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ctx, "b", []byte("2"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok, err := r.Get(ctx, "a"); !ok || err != nil || string(value) != "1" {
+		t.Fatalf("Get(a) = %q, %t, %v", value, ok, err)
+	}
+	if !server.Exists("test:a") {
+		t.Error("key is not stored under the prefix")
+	}
+	if server.Exists("test:b") {
+		t.Error("an entry without TTL was stored")
+	}
+
+	server.FastForward(time.Minute)
+	if _, ok, _ := r.Get(ctx, "a"); ok {
+		t.Error("a is still there after the TTL")
+	}
+}
+
+func TestRedisDelete(t *testing.T) {
+	r, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	r.Set(ctx, "a", []byte("1"), time.Minute)
+	r.Set(ctx, "b", []byte("2"), time.Minute)
+	if err := r.Delete(ctx, "a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := r.Get(ctx, "a"); ok {
+		t.Error("a was not deleted")
+	}
+	if _, ok, _ := r.Get(ctx, "b"); !ok {
+		t.Error("b was deleted")
+	}
+}
+
+func TestRedisInvalidatesTags(t *testing.T) {
+	r, server := newTestRedis(t)
+	ctx := context.Background()
+
+	r.Set(ctx, "list:1", []byte("1"), time.Minute, "tenant:1")
+	r.Set(ctx, "list:2", []byte("2"), time.Minute, "tenant:1", "search")
+	r.Set(ctx, "list:3", []byte("3"), time.Minute, "tenant:2")
+
+	if ttl := server.TTL("test:tag:tenant:1"); ttl != time.Minute {
+		t.Errorf("tag expires in %s, want 1m", ttl)
+	}
+
+	if err := r.InvalidateTags(ctx, "tenant:1", "unknown"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"list:1": false, "list:2": false, "list:3": true} {
+		if _, ok, _ := r.Get(ctx, key); ok != want {
+			t.Errorf("%s present = %t, want %t", key, ok, want)
+		}
+	}
+	if server.Exists("test:tag:tenant:1") {
+		t.Error("the tag set was not removed")
+	}
+
+	// Keys tagged after an invalidation go into a new set.
+	r.Set(ctx, "list:1", []byte("1"), time.Minute, "tenant:1")
+	r.InvalidateTags(ctx, "tenant:1")
+	if _, ok, _ := r.Get(ctx, "list:1"); ok {
+		t.Error("a key tagged after an invalidation was not invalidated")
+	}
+}
+
+func TestRedisErrorsAreReported(t *testing.T) {
+	r, server := newTestRedis(t)
+	ctx := context.Background()
+
+	server.Close()
+
+	if _, ok, err := r.Get(ctx, "a"); ok || err == nil {
+		t.Errorf("Get = %t, %v, want an error", ok, err)
+	}
+	if err := r.Set(ctx, "a", []byte("1"), time.Minute, "tag"); err == nil {
+		t.Error("Set did not report an error")
+	}
+	if err := r.InvalidateTags(ctx, "tag"); err == nil {
+		t.Error("InvalidateTags did not report an error")
+	}
+}
@@ -0,0 +1,71 @@
+package docs
+
+var APIKeyEndpoints = map[string]EndpointDoc{
+	"ListAPIKeys": {
+		Summary:     "List API keys",
+		Description: "List the API keys created by the current user. Key secrets are never returned",
+		Tags:        []string{"api-keys"},
+		Method:      "GET",
+		Path:        "/v1/api-keys",
+		Permission:  "apikeys:manage",
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "APIKeyListResponse",
+			},
+		},
+	},
+	"CreateAPIKey": {
+		Summary:     "Create an API key",
+		Description: "Create a scoped API key for machine clients. Scopes are limited to permissions the caller holds. The key is only returned in this response",
+		Tags:        []string{"api-keys"},
+		Method:      "POST",
+		Path:        "/v1/api-keys",
+		Permission:  "apikeys:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "key",
+				In:          "body",
+				Required:    true,
+				Schema:      "CreateAPIKeyRequest",
+				Description: "Key name, scopes and optional expiry",
+			},
+		},
+		Responses: map[int]Response{
+			201: {
+				Description: "API key created",
+				Schema:      "CreatedAPIKeyResponse",
+			},
+			400: {
+				Description: "Invalid name, scope or expiry",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"RevokeAPIKey": {
+		Summary:     "Revoke an API key",
+		Description: "Revoke one of the current user's API keys",
+		Tags:        []string{"api-keys"},
+		Method:      "DELETE",
+		Path:        "/v1/api-keys/{id}",
+		Permission:  "apikeys:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "API key ID",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "API key revoked",
+			},
+			404: {
+				Description: "API key not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+}
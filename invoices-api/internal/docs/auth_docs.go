@@ -0,0 +1,105 @@
+package docs
+
+var AuthEndpoints = map[string]EndpointDoc{
+	"Login": {
+		Summary:     "Log in",
+		Description: "Exchange an email and password for an access token and a refresh token",
+		Tags:        []string{"auth"},
+		Method:      "POST",
+		Path:        "/v1/auth/login",
+		Public:      true,
+		Parameters: []Parameter{
+			{
+				Name:        "credentials",
+				In:          "body",
+				Required:    true,
+				Schema:      "LoginRequest",
+				Description: "User credentials",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Login successful",
+				Schema:      "TokenResponse",
+			},
+			400: {
+				Description: "Invalid request body",
+				Schema:      "ErrorResponse",
+			},
+			401: {
+				Description: "Invalid email or password",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"Refresh": {
+		Summary:     "Refresh tokens",
+		Description: "Exchange a refresh token for a new token pair. Refresh tokens are single use; reusing one revokes all sessions of the user",
+		Tags:        []string{"auth"},
+		Method:      "POST",
+		Path:        "/v1/auth/refresh",
+		Public:      true,
+		Parameters: []Parameter{
+			{
+				Name:        "token",
+				In:          "body",
+				Required:    true,
+				Schema:      "RefreshRequest",
+				Description: "Refresh token",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Token refreshed",
+				Schema:      "TokenResponse",
+			},
+			400: {
+				Description: "Invalid request body",
+				Schema:      "ErrorResponse",
+			},
+			401: {
+				Description: "Invalid, expired or reused refresh token",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"Logout": {
+		Summary:     "Log out",
+		Description: "Revoke a refresh token. Access tokens stay valid until they expire",
+		Tags:        []string{"auth"},
+		Method:      "POST",
+		Path:        "/v1/auth/logout",
+		Public:      true,
+		Parameters: []Parameter{
+			{
+				Name:        "token",
+				In:          "body",
+				Required:    true,
+				Schema:      "RefreshRequest",
+				Description: "Refresh token to revoke",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Logged out",
+			},
+			400: {
+				Description: "Invalid request body",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"Me": {
+		Summary:     "Current user",
+		Description: "Get the user the access token was issued to",
+		Tags:        []string{"auth"},
+		Method:      "GET",
+		Path:        "/v1/auth/me",
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "UserResponse",
+			},
+		},
+	},
+}
@@ -13,6 +13,12 @@ type EndpointDoc struct {
 	Path        string
 	Parameters  []Parameter
 	Responses   map[int]Response
+	Produces    []string
+	Consumes    []string
+	// Public endpoints can be called without a bearer token.
+	Public bool
+	// Permission is the RBAC permission the route is guarded by.
+	Permission string
 }
 
 type Parameter struct {
@@ -30,25 +36,127 @@ type Response struct {
 	Schema      string
 }
 
+// tenantParameter is added to every tenant-scoped endpoint.
+var tenantParameter = Parameter{
+	Name:        "X-Tenant-ID",
+	In:          "header",
+	Type:        "integer",
+	Required:    false,
+	Description: "Organization to act on. Defaults to the caller's own organization; other values require tenants:switch",
+}
+
+// idempotencyParameter is added to every tenant-scoped POST, PUT and DELETE
+// endpoint.
+var idempotencyParameter = Parameter{
+	Name:        "Idempotency-Key",
+	In:          "header",
+	Type:        "string",
+	Required:    false,
+	Description: "Unique key for this request. Retries with the same key replay the first response instead of repeating the change",
+}
+
+// conditionalParameters are accepted by GET endpoints that answer with an
+// ETag and Last-Modified.
+var conditionalParameters = []Parameter{
+	{
+		Name:        "If-None-Match",
+		In:          "header",
+		Type:        "string",
+		Required:    false,
+		Description: "ETag of a cached response; 304 is returned while it is current",
+	},
+	{
+		Name:        "If-Modified-Since",
+		In:          "header",
+		Type:        "string",
+		Required:    false,
+		Description: "Last-Modified of a cached response; ignored when If-None-Match is sent",
+	},
+}
+
 func GenerateSwaggerSpec() map[string]any {
 	paths := make(map[string]any)
 
-	for _, endpoint := range InvoiceEndpoints {
+	for _, endpoint := range allEndpoints() {
 		if _, exists := paths[endpoint.Path]; !exists {
 			paths[endpoint.Path] = make(map[string]any)
 		}
 
-		method := strings.ToLower(endpoint.Method)
-		pathMap := paths[endpoint.Path].(map[string]any)
-		pathMap[method] = map[string]any{
+		produces := endpoint.Produces
+		if len(produces) == 0 {
+			produces = []string{"application/json"}
+		}
+
+		consumes := endpoint.Consumes
+		if len(consumes) == 0 {
+			consumes = []string{"application/json"}
+		}
+
+		responses := generateResponsesSpec(endpoint.Responses)
+		if !endpoint.Public {
+			if _, exists := responses["401"]; !exists {
+				responses["401"] = map[string]any{
+					"description": "Missing or invalid bearer token",
+					"schema":      map[string]any{"$ref": "#/definitions/ErrorResponse"},
+				}
+			}
+		}
+
+		if _, exists := responses["429"]; !exists {
+			responses["429"] = map[string]any{
+				"description": "Rate limit exceeded; retry after the number of seconds in the Retry-After header",
+				"schema":      map[string]any{"$ref": "#/definitions/ErrorResponse"},
+			}
+		}
+
+		description := endpoint.Description
+		if endpoint.Permission != "" {
+			description = fmt.Sprintf("%s. Requires the `%s` permission.", strings.TrimSuffix(description, "."), endpoint.Permission)
+			if _, exists := responses["403"]; !exists {
+				responses["403"] = map[string]any{
+					"description": "Insufficient permissions",
+					"schema":      map[string]any{"$ref": "#/definitions/ErrorResponse"},
+				}
+			}
+		}
+
+		parameters := endpoint.Parameters
+		if endpoint.Permission != "" {
+			parameters = append(parameters, tenantParameter)
+		}
+
+		if endpoint.Permission != "" && endpoint.Method != "GET" {
+			parameters = append(parameters, idempotencyParameter)
+			if _, exists := responses["409"]; !exists {
+				responses["409"] = map[string]any{
+					"description": "A request with the same Idempotency-Key is still being processed",
+					"schema":      map[string]any{"$ref": "#/definitions/ErrorResponse"},
+				}
+			}
+			if _, exists := responses["422"]; !exists {
+				responses["422"] = map[string]any{
+					"description": "The Idempotency-Key was already used for a different request",
+					"schema":      map[string]any{"$ref": "#/definitions/ErrorResponse"},
+				}
+			}
+		}
+
+		operation := map[string]any{
 			"tags":        endpoint.Tags,
 			"summary":     endpoint.Summary,
-			"description": endpoint.Description,
-			"parameters":  generateParametersSpec(endpoint.Parameters),
-			"responses":   generateResponsesSpec(endpoint.Responses),
-			"produces":    []string{"application/json"},
-			"consumes":    []string{"application/json"},
+			"description": description,
+			"parameters":  generateParametersSpec(parameters),
+			"responses":   responses,
+			"produces":    produces,
+			"consumes":    consumes,
+		}
+		if endpoint.Public {
+			operation["security"] = []any{}
 		}
+
+		method := strings.ToLower(endpoint.Method)
+		pathMap := paths[endpoint.Path].(map[string]any)
+		pathMap[method] = operation
 	}
 
 	return map[string]any{
@@ -63,9 +171,45 @@ func GenerateSwaggerSpec() map[string]any {
 		"schemes":     SwaggerInfo.Schemes,
 		"paths":       paths,
 		"definitions": ModelDefinitions,
+		"securityDefinitions": map[string]any{
+			"Bearer": map[string]any{
+				"type":        "apiKey",
+				"name":        "Authorization",
+				"in":          "header",
+				"description": "Access token from /v1/auth/login or an API key, sent as \"Bearer <token>\"",
+			},
+			"APIKey": map[string]any{
+				"type": "apiKey",
+				"name": "X-API-Key",
+				"in":   "header",
+			},
+		},
+		"security": []map[string][]string{
+			{"Bearer": {}},
+			{"APIKey": {}},
+		},
 	}
 }
 
+func allEndpoints() []EndpointDoc {
+	groups := []map[string]EndpointDoc{
+		AuthEndpoints,
+		APIKeyEndpoints,
+		WebhookEndpoints,
+		OrganizationEndpoints,
+		InvoiceEndpoints,
+		ReportEndpoints,
+	}
+
+	var endpoints []EndpointDoc
+	for _, group := range groups {
+		for _, endpoint := range group {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
 func generateParametersSpec(params []Parameter) []map[string]any {
 	result := make([]map[string]any, 0)
 	for _, param := range params {
@@ -95,12 +239,21 @@ func generateParametersSpec(params []Parameter) []map[string]any {
 func generateResponsesSpec(responses map[int]Response) map[string]any {
 	result := make(map[string]any)
 	for code, response := range responses {
-		result[fmt.Sprint(code)] = map[string]any{
+		responseSpec := map[string]any{
 			"description": response.Description,
-			"schema": map[string]any{
+		}
+
+		switch response.Schema {
+		case "":
+		case "file":
+			responseSpec["schema"] = map[string]any{"type": "file"}
+		default:
+			responseSpec["schema"] = map[string]any{
 				"$ref": fmt.Sprintf("#/definitions/%s", response.Schema),
-			},
+			}
 		}
+
+		result[fmt.Sprint(code)] = responseSpec
 	}
 	return result
 }
@@ -7,6 +7,7 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 		Tags:        []string{"invoices"},
 		Method:      "GET",
 		Path:        "/v1/invoices",
+		Permission:  "invoices:read",
 		Parameters: []Parameter{
 			{
 				Name:        "page",
@@ -36,7 +37,7 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 				In:          "query",
 				Type:        "string",
 				Required:    false,
-				Description: "Field to sort by",
+				Description: "Field to sort by: id, service_name, invoice_number, date, amount, status, customer_email, due_date, created_at or updated_at",
 			},
 			{
 				Name:        "sort_dir",
@@ -46,12 +47,17 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 				Default:     "asc",
 				Description: "Sort direction (asc/desc)",
 			},
+			conditionalParameters[0],
+			conditionalParameters[1],
 		},
 		Responses: map[int]Response{
 			200: {
 				Description: "Successful response",
 				Schema:      "InvoiceListResponse",
 			},
+			304: {
+				Description: "The cached page is still current",
+			},
 			500: {
 				Description: "Internal server error",
 				Schema:      "ErrorResponse",
@@ -64,6 +70,7 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 		Tags:        []string{"invoices"},
 		Method:      "GET",
 		Path:        "/v1/invoices/{id}",
+		Permission:  "invoices:read",
 		Parameters: []Parameter{
 			{
 				Name:        "id",
@@ -72,12 +79,17 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 				Required:    true,
 				Description: "Invoice ID",
 			},
+			conditionalParameters[0],
+			conditionalParameters[1],
 		},
 		Responses: map[int]Response{
 			200: {
 				Description: "Successful response",
 				Schema:      "InvoiceResponse",
 			},
+			304: {
+				Description: "The cached invoice is still current",
+			},
 			404: {
 				Description: "Invoice not found",
 				Schema:      "ErrorResponse",
@@ -86,10 +98,11 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 	},
 	"CreateInvoice": {
 		Summary:     "Create new invoice",
-		Description: "Create a new invoice with the provided details",
+		Description: "Create a new invoice with the provided details. Creating an invoice with status Paid also requires `invoices:mark_paid`",
 		Tags:        []string{"invoices"},
 		Method:      "POST",
 		Path:        "/v1/invoices",
+		Permission:  "invoices:write",
 		Parameters: []Parameter{
 			{
 				Name:        "body",
@@ -118,10 +131,11 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 
 	"UpdateInvoice": {
 		Summary:     "Update invoice",
-		Description: "Update an existing invoice by ID",
+		Description: "Update an existing invoice by ID. Changing the status to Paid also requires `invoices:mark_paid`",
 		Tags:        []string{"invoices"},
 		Method:      "PUT",
 		Path:        "/v1/invoices/{id}",
+		Permission:  "invoices:write",
 		Parameters: []Parameter{
 			{
 				Name:        "id",
@@ -165,6 +179,7 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 		Tags:        []string{"invoices"},
 		Method:      "DELETE",
 		Path:        "/v1/invoices/{id}",
+		Permission:  "invoices:delete",
 		Parameters: []Parameter{
 			{
 				Name:        "id",
@@ -189,4 +204,291 @@ var InvoiceEndpoints = map[string]EndpointDoc{
 			},
 		},
 	},
+
+	"StreamInvoices": {
+		Summary:     "Stream invoice events",
+		Description: "Receive invoice events of the organization as Server-Sent Events. Each event has the outbox ID as its id, the event type as its name and a WebhookEvent as data. A comment is sent every 15 seconds as a heartbeat. After reconnecting with Last-Event-ID, missed events are sent first. If too many were missed, a reset event is sent instead and the client should reload.",
+		Tags:        []string{"invoices"},
+		Method:      "GET",
+		Path:        "/v1/invoices/stream",
+		Permission:  "invoices:read",
+		Produces:    []string{"text/event-stream"},
+		Parameters: []Parameter{
+			{
+				Name:        "events",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Comma separated event types to receive (invoice.created, invoice.updated, invoice.paid, invoice.deleted). All by default",
+			},
+			{
+				Name:        "invoice_id",
+				In:          "query",
+				Type:        "integer",
+				Required:    false,
+				Description: "Only events of this invoice",
+			},
+			{
+				Name:        "Last-Event-ID",
+				In:          "header",
+				Type:        "integer",
+				Required:    false,
+				Description: "ID of the last event received; missed events are sent first",
+			},
+			{
+				Name:        "last_event_id",
+				In:          "query",
+				Type:        "integer",
+				Required:    false,
+				Description: "Same as the Last-Event-ID header, for clients that cannot set it",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Event stream",
+			},
+			400: {
+				Description: "Unknown event type or invalid ID",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"ExportInvoices": {
+		Summary:     "Export invoices",
+		Description: "Download invoices matching the list filters as a CSV or XLSX spreadsheet. Pagination parameters are ignored.",
+		Tags:        []string{"invoices"},
+		Method:      "GET",
+		Path:        "/v1/invoices/export",
+		Permission:  "invoices:export",
+		Produces: []string{
+			"text/csv",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		},
+		Parameters: []Parameter{
+			{
+				Name:        "format",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Default:     "csv",
+				Description: "Export format (csv/xlsx)",
+			},
+			{
+				Name:        "columns",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Comma separated columns: id, invoice_number, service_name, date, amount, status, created_at, updated_at",
+			},
+			{
+				Name:        "locale",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Default:     "en-US",
+				Description: "Number and date formatting (en-US, en-GB, de-DE, fr-FR, tr-TR, iso)",
+			},
+			{
+				Name:        "search",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Search by service name",
+			},
+			{
+				Name:        "sort_by",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Field to sort by: id, service_name, invoice_number, date, amount, status, customer_email, due_date, created_at or updated_at",
+			},
+			{
+				Name:        "sort_dir",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Default:     "asc",
+				Description: "Sort direction (asc/desc)",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Spreadsheet file",
+				Schema:      "file",
+			},
+			400: {
+				Description: "Invalid export parameters",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+
+	"ImportInvoices": {
+		Summary:     "Import invoices",
+		Description: "Bulk import invoices from CSV or JSON Lines. Every row is validated and checked for duplicate invoice numbers before valid rows are committed in batched transactions.",
+		Tags:        []string{"invoices"},
+		Method:      "POST",
+		Path:        "/v1/invoices/import",
+		Permission:  "invoices:import",
+		Consumes:    []string{"multipart/form-data", "text/csv", "application/x-ndjson"},
+		Parameters: []Parameter{
+			{
+				Name:        "file",
+				In:          "formData",
+				Type:        "file",
+				Required:    false,
+				Description: "Import file. The raw request body is used when no file is uploaded",
+			},
+			{
+				Name:        "format",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Input format (csv/jsonl). Detected from the file extension or Content-Type when omitted",
+			},
+			{
+				Name:        "dry_run",
+				In:          "query",
+				Type:        "boolean",
+				Required:    false,
+				Default:     "false",
+				Description: "Validate only, without writing any invoices",
+			},
+			{
+				Name:        "batch_size",
+				In:          "query",
+				Type:        "integer",
+				Required:    false,
+				Default:     "500",
+				Description: "Rows committed per transaction",
+			},
+			{
+				Name:        "locale",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Default:     "en-US",
+				Description: "Number and date format of CSV values",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Import report",
+				Schema:      "ImportResponse",
+			},
+			400: {
+				Description: "Unreadable import file",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+
+	"BatchInvoices": {
+		Summary:     "Batch invoice operations",
+		Description: "Apply a list of create, update, patch and delete operations. In atomic mode all operations run in one transaction and the batch is rolled back on the first failure. In best_effort mode each operation runs on its own and the response reports a result per item.",
+		Tags:        []string{"invoices"},
+		Method:      "POST",
+		Path:        "/v1/invoices/batch",
+		Permission:  "invoices:write",
+		Parameters: []Parameter{
+			{
+				Name:        "body",
+				In:          "body",
+				Type:        "object",
+				Required:    true,
+				Schema:      "BatchRequest",
+				Description: "Batch mode and operations",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Batch processed",
+				Schema:      "BatchResponse",
+			},
+			400: {
+				Description: "Invalid batch or atomic batch rolled back",
+				Schema:      "ErrorResponse",
+			},
+			404: {
+				Description: "Atomic batch rolled back because an invoice was not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+
+	"SendInvoice": {
+		Summary:     "Email an invoice",
+		Description: "Send the invoice as a PDF attachment to the given recipient, or to the invoice's customer_email when none is given. Every attempt is recorded in the invoice's send log. Returns 503 when email delivery is not configured.",
+		Tags:        []string{"invoices"},
+		Method:      "POST",
+		Path:        "/v1/invoices/{id}/send",
+		Permission:  "invoices:send",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Invoice ID",
+			},
+			{
+				Name:        "body",
+				In:          "body",
+				Type:        "object",
+				Required:    false,
+				Schema:      "SendInvoiceRequest",
+				Description: "Recipient override and an optional personal message",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Invoice sent",
+				Schema:      "InvoiceEmailResponse",
+			},
+			400: {
+				Description: "Invalid recipient or no recipient available",
+				Schema:      "ErrorResponse",
+			},
+			404: {
+				Description: "Invoice not found",
+				Schema:      "ErrorResponse",
+			},
+			502: {
+				Description: "The mail server rejected the email; the failed attempt is in details",
+				Schema:      "ErrorResponse",
+			},
+			503: {
+				Description: "Email delivery is not configured",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+
+	"ListInvoiceEmails": {
+		Summary:     "List emails sent for an invoice",
+		Description: "Get the send log of an invoice, including payment reminders and failed attempts, newest first",
+		Tags:        []string{"invoices"},
+		Method:      "GET",
+		Path:        "/v1/invoices/{id}/emails",
+		Permission:  "invoices:read",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Invoice ID",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "InvoiceEmailListResponse",
+			},
+			404: {
+				Description: "Invoice not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
 }
@@ -8,6 +8,11 @@ var ModelDefinitions = map[string]map[string]any{
 				"type":    "integer",
 				"example": 1,
 			},
+			"tenant_id": map[string]any{
+				"type":     "integer",
+				"example":  1,
+				"readOnly": true,
+			},
 			"service_name": map[string]any{
 				"type":    "string",
 				"example": "DMP Service",
@@ -31,6 +36,17 @@ var ModelDefinitions = map[string]map[string]any{
 				"enum":    []string{"Paid", "Pending", "Unpaid"},
 				"example": "Pending",
 			},
+			"customer_email": map[string]any{
+				"type":    "string",
+				"format":  "email",
+				"example": "billing@customer.com",
+			},
+			"due_date": map[string]any{
+				"type":        "string",
+				"format":      "date-time",
+				"example":     "2024-04-15T00:00:00Z",
+				"description": "Defaults to date plus the configured payment terms",
+			},
 			"created_at": map[string]any{
 				"type":   "string",
 				"format": "date-time",
@@ -110,4 +126,670 @@ var ModelDefinitions = map[string]map[string]any{
 			},
 		},
 	},
+	"ImportRowError": {
+		"type": "object",
+		"properties": map[string]any{
+			"row": map[string]any{
+				"type":    "integer",
+				"example": 3,
+			},
+			"invoice_number": map[string]any{
+				"type":    "integer",
+				"example": 1001,
+			},
+			"errors": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"$ref": "#/definitions/ValidationError",
+				},
+			},
+		},
+	},
+	"ValidationError": {
+		"type": "object",
+		"properties": map[string]any{
+			"field": map[string]any{
+				"type":    "string",
+				"example": "Amount",
+			},
+			"message": map[string]any{
+				"type":    "string",
+				"example": "Amount must be greater than 0",
+			},
+		},
+	},
+	"ImportResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":    "string",
+				"example": "Import completed",
+			},
+			"data": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"dry_run": map[string]any{
+						"type": "boolean",
+					},
+					"total_rows": map[string]any{
+						"type":    "integer",
+						"example": 1200,
+					},
+					"valid_rows": map[string]any{
+						"type":    "integer",
+						"example": 1195,
+					},
+					"imported": map[string]any{
+						"type":    "integer",
+						"example": 1195,
+					},
+					"failed": map[string]any{
+						"type":    "integer",
+						"example": 5,
+					},
+					"errors": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"$ref": "#/definitions/ImportRowError",
+						},
+					},
+					"errors_truncated": map[string]any{
+						"type":        "boolean",
+						"description": "Set when more rows failed than are listed in errors",
+					},
+				},
+			},
+		},
+	},
+	"BatchOperation": {
+		"type": "object",
+		"properties": map[string]any{
+			"op": map[string]any{
+				"type":    "string",
+				"enum":    []string{"create", "update", "patch", "delete"},
+				"example": "patch",
+			},
+			"id": map[string]any{
+				"type":    "integer",
+				"example": 42,
+			},
+			"data": map[string]any{
+				"type":        "object",
+				"description": "Full invoice for create/update, changed fields only for patch",
+				"example":     map[string]any{"status": "Paid"},
+			},
+		},
+		"required": []string{"op"},
+	},
+	"BatchRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"mode": map[string]any{
+				"type":    "string",
+				"enum":    []string{"atomic", "best_effort"},
+				"example": "atomic",
+			},
+			"operations": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"$ref": "#/definitions/BatchOperation",
+				},
+			},
+		},
+		"required": []string{"operations"},
+	},
+	"BatchResult": {
+		"type": "object",
+		"properties": map[string]any{
+			"index": map[string]any{
+				"type":    "integer",
+				"example": 0,
+			},
+			"op": map[string]any{
+				"type":    "string",
+				"example": "patch",
+			},
+			"id": map[string]any{
+				"type":    "integer",
+				"example": 42,
+			},
+			"status": map[string]any{
+				"type":    "integer",
+				"example": 200,
+			},
+			"data": map[string]any{
+				"$ref": "#/definitions/Invoice",
+			},
+			"error": map[string]any{
+				"$ref": "#/definitions/ErrorResponse",
+			},
+		},
+	},
+	"BatchResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":    "string",
+				"example": "Batch processed",
+			},
+			"data": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"mode": map[string]any{
+						"type":    "string",
+						"example": "best_effort",
+					},
+					"succeeded": map[string]any{
+						"type":    "integer",
+						"example": 199,
+					},
+					"failed": map[string]any{
+						"type":    "integer",
+						"example": 1,
+					},
+					"results": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"$ref": "#/definitions/BatchResult",
+						},
+					},
+				},
+			},
+		},
+	},
+	"SummaryGroup": {
+		"type": "object",
+		"properties": map[string]any{
+			"key": map[string]any{
+				"type":    "string",
+				"example": "Paid",
+			},
+			"count": map[string]any{
+				"type":    "integer",
+				"example": 12,
+			},
+			"amount": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 18006.00,
+			},
+			"average": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 1500.50,
+			},
+		},
+	},
+	"SummaryResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"data": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"from": map[string]any{
+						"type":   "string",
+						"format": "date-time",
+					},
+					"to": map[string]any{
+						"type":   "string",
+						"format": "date-time",
+					},
+					"period": map[string]any{
+						"type":    "string",
+						"example": "month",
+					},
+					"totals": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"count": map[string]any{
+								"type":    "integer",
+								"example": 42,
+							},
+							"amount": map[string]any{
+								"type":    "number",
+								"format":  "double",
+								"example": 63021.50,
+							},
+							"average": map[string]any{
+								"type":    "number",
+								"format":  "double",
+								"example": 1500.51,
+							},
+						},
+					},
+					"by_status": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"$ref": "#/definitions/SummaryGroup"},
+					},
+					"by_service": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"$ref": "#/definitions/SummaryGroup"},
+					},
+					"by_period": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"$ref": "#/definitions/SummaryGroup"},
+					},
+				},
+			},
+		},
+	},
+	"RevenuePoint": {
+		"type": "object",
+		"properties": map[string]any{
+			"bucket": map[string]any{
+				"type":   "string",
+				"format": "date-time",
+			},
+			"amount": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 4500.75,
+			},
+			"count": map[string]any{
+				"type":    "integer",
+				"example": 3,
+			},
+			"running_total": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 12000.00,
+			},
+			"moving_average": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 3900.25,
+			},
+			"previous_amount": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 3000.00,
+			},
+			"change_pct": map[string]any{
+				"type":    "number",
+				"format":  "double",
+				"example": 50.03,
+			},
+		},
+	},
+	"RevenueSeries": {
+		"type": "object",
+		"properties": map[string]any{
+			"service_name": map[string]any{
+				"type":    "string",
+				"example": "DMP Service",
+			},
+			"points": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/definitions/RevenuePoint"},
+			},
+		},
+	},
+	"PeriodComparison": {
+		"type": "object",
+		"properties": map[string]any{
+			"current_from":    map[string]any{"type": "string", "format": "date-time"},
+			"current_to":      map[string]any{"type": "string", "format": "date-time"},
+			"previous_from":   map[string]any{"type": "string", "format": "date-time"},
+			"previous_to":     map[string]any{"type": "string", "format": "date-time"},
+			"current_amount":  map[string]any{"type": "number", "format": "double", "example": 12000.00},
+			"previous_amount": map[string]any{"type": "number", "format": "double", "example": 10000.00},
+			"current_count":   map[string]any{"type": "integer", "example": 8},
+			"previous_count":  map[string]any{"type": "integer", "example": 7},
+			"change":          map[string]any{"type": "number", "format": "double", "example": 2000.00},
+			"change_pct":      map[string]any{"type": "number", "format": "double", "example": 20},
+		},
+	},
+	"RevenueResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"data": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"interval": map[string]any{
+						"type":    "string",
+						"example": "day",
+					},
+					"from": map[string]any{
+						"type":   "string",
+						"format": "date-time",
+					},
+					"to": map[string]any{
+						"type":   "string",
+						"format": "date-time",
+					},
+					"window": map[string]any{
+						"type":    "integer",
+						"example": 7,
+					},
+					"total": map[string]any{
+						"$ref": "#/definitions/RevenueSeries",
+					},
+					"series": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"$ref": "#/definitions/RevenueSeries"},
+					},
+					"comparison": map[string]any{
+						"$ref": "#/definitions/PeriodComparison",
+					},
+				},
+			},
+		},
+	},
+	"LoginRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"email": map[string]any{
+				"type":    "string",
+				"format":  "email",
+				"example": "admin@example.com",
+			},
+			"password": map[string]any{
+				"type":   "string",
+				"format": "password",
+			},
+		},
+		"required": []string{"email", "password"},
+	},
+	"RefreshRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"refresh_token": map[string]any{
+				"type": "string",
+			},
+		},
+		"required": []string{"refresh_token"},
+	},
+	"TokenResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":    "string",
+				"example": "Login successful",
+			},
+			"data": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"access_token":  map[string]any{"type": "string"},
+					"refresh_token": map[string]any{"type": "string"},
+					"token_type":    map[string]any{"type": "string", "example": "Bearer"},
+					"expires_in":    map[string]any{"type": "integer", "example": 900},
+				},
+			},
+		},
+	},
+	"User": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":            map[string]any{"type": "integer", "example": 1},
+			"tenant_id":     map[string]any{"type": "integer", "example": 1},
+			"role":          map[string]any{"type": "string", "example": "admin"},
+			"email":         map[string]any{"type": "string", "example": "admin@example.com"},
+			"name":          map[string]any{"type": "string", "example": "Administrator"},
+			"active":        map[string]any{"type": "boolean", "example": true},
+			"last_login_at": map[string]any{"type": "string", "format": "date-time"},
+			"created_at":    map[string]any{"type": "string", "format": "date-time"},
+			"updated_at":    map[string]any{"type": "string", "format": "date-time"},
+		},
+	},
+	"UserResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":    "string",
+				"example": "User retrieved successfully",
+			},
+			"data": map[string]any{
+				"$ref": "#/definitions/User",
+			},
+		},
+	},
+	"APIKey": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":           map[string]any{"type": "integer", "example": 1},
+			"user_id":      map[string]any{"type": "integer", "example": 1},
+			"name":         map[string]any{"type": "string", "example": "billing-cron"},
+			"prefix":       map[string]any{"type": "string", "example": "inv_3f9a1c2e"},
+			"scopes":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "example": []string{"invoices:read", "invoices:write"}},
+			"expires_at":   map[string]any{"type": "string", "format": "date-time"},
+			"last_used_at": map[string]any{"type": "string", "format": "date-time"},
+			"revoked_at":   map[string]any{"type": "string", "format": "date-time"},
+			"created_at":   map[string]any{"type": "string", "format": "date-time"},
+		},
+	},
+	"CreateAPIKeyRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"name":       map[string]any{"type": "string", "example": "billing-cron"},
+			"scopes":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "example": []string{"invoices:read", "invoices:write"}},
+			"expires_at": map[string]any{"type": "string", "format": "date-time"},
+		},
+		"required": []string{"name", "scopes"},
+	},
+	"APIKeyListResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string", "example": "API keys retrieved successfully"},
+			"data": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/definitions/APIKey"},
+			},
+		},
+	},
+	"CreatedAPIKeyResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string"},
+			"data": map[string]any{
+				"allOf": []any{
+					map[string]any{"$ref": "#/definitions/APIKey"},
+					map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"key": map[string]any{"type": "string", "example": "inv_3f9a1c2e_..."},
+						},
+					},
+				},
+			},
+		},
+	},
+	"Organization": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":         map[string]any{"type": "integer", "example": 2},
+			"name":       map[string]any{"type": "string", "example": "Acme GmbH"},
+			"slug":       map[string]any{"type": "string", "example": "acme-gmbh"},
+			"created_at": map[string]any{"type": "string", "format": "date-time"},
+			"updated_at": map[string]any{"type": "string", "format": "date-time"},
+		},
+	},
+	"CreateOrganizationRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "example": "Acme GmbH"},
+			"slug": map[string]any{"type": "string", "example": "acme-gmbh"},
+		},
+		"required": []string{"name", "slug"},
+	},
+	"OrganizationResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string", "example": "Organization created successfully"},
+			"data":    map[string]any{"$ref": "#/definitions/Organization"},
+		},
+	},
+	"OrganizationListResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string", "example": "Organizations retrieved successfully"},
+			"data": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/definitions/Organization"},
+			},
+		},
+	},
+	"Webhook": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":          map[string]any{"type": "integer", "example": 1},
+			"tenant_id":   map[string]any{"type": "integer", "example": 1},
+			"url":         map[string]any{"type": "string", "example": "https://hooks.example.com/invoices"},
+			"description": map[string]any{"type": "string", "example": "Accounting sync"},
+			"events":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "example": []string{"invoice.created", "invoice.paid"}},
+			"active":      map[string]any{"type": "boolean", "example": true},
+			"created_at":  map[string]any{"type": "string", "format": "date-time"},
+			"updated_at":  map[string]any{"type": "string", "format": "date-time"},
+		},
+	},
+	"CreateWebhookRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"url":         map[string]any{"type": "string", "example": "https://hooks.example.com/invoices"},
+			"description": map[string]any{"type": "string", "example": "Accounting sync"},
+			"events": map[string]any{
+				"type":    "array",
+				"items":   map[string]any{"type": "string", "enum": []string{"invoice.created", "invoice.updated", "invoice.paid", "invoice.deleted", "*"}},
+				"example": []string{"invoice.created", "invoice.paid"},
+			},
+		},
+		"required": []string{"url", "events"},
+	},
+	"WebhookResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"data": map[string]any{"$ref": "#/definitions/Webhook"},
+		},
+	},
+	"WebhookListResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string", "example": "Webhooks retrieved successfully"},
+			"data": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/definitions/Webhook"},
+			},
+		},
+	},
+	"CreatedWebhookResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string"},
+			"data": map[string]any{
+				"allOf": []any{
+					map[string]any{"$ref": "#/definitions/Webhook"},
+					map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"secret": map[string]any{"type": "string", "example": "whsec_..."},
+						},
+					},
+				},
+			},
+		},
+	},
+	"WebhookDelivery": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":              map[string]any{"type": "integer", "example": 42},
+			"tenant_id":       map[string]any{"type": "integer", "example": 1},
+			"subscription_id": map[string]any{"type": "integer", "example": 1},
+			"event_id":        map[string]any{"type": "string", "example": "evt_5d41402abc4b2a76b9719d911017c592"},
+			"event":           map[string]any{"type": "string", "example": "invoice.paid"},
+			"payload":         map[string]any{"$ref": "#/definitions/WebhookEvent"},
+			"status":          map[string]any{"type": "string", "enum": []string{"pending", "succeeded", "failed"}},
+			"attempts":        map[string]any{"type": "integer", "example": 1},
+			"next_attempt_at": map[string]any{"type": "string", "format": "date-time"},
+			"last_attempt_at": map[string]any{"type": "string", "format": "date-time"},
+			"response_status": map[string]any{"type": "integer", "example": 200},
+			"response_body":   map[string]any{"type": "string"},
+			"last_error":      map[string]any{"type": "string"},
+			"created_at":      map[string]any{"type": "string", "format": "date-time"},
+			"updated_at":      map[string]any{"type": "string", "format": "date-time"},
+		},
+	},
+	"WebhookEvent": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":         map[string]any{"type": "string", "example": "evt_5d41402abc4b2a76b9719d911017c592"},
+			"type":       map[string]any{"type": "string", "example": "invoice.paid"},
+			"created_at": map[string]any{"type": "string", "format": "date-time"},
+			"tenant_id":  map[string]any{"type": "integer", "example": 1},
+			"data":       map[string]any{"$ref": "#/definitions/Invoice"},
+		},
+	},
+	"WebhookDeliveryResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string", "example": "Webhook delivery queued"},
+			"data":    map[string]any{"$ref": "#/definitions/WebhookDelivery"},
+		},
+	},
+	"WebhookDeliveryListResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"data": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/definitions/WebhookDelivery"},
+			},
+			"meta": map[string]any{"$ref": "#/definitions/MetaData"},
+		},
+	},
+	"SendInvoiceRequest": {
+		"type": "object",
+		"properties": map[string]any{
+			"to": map[string]any{
+				"type":        "string",
+				"format":      "email",
+				"example":     "billing@customer.com",
+				"description": "Defaults to the invoice's customer_email",
+			},
+			"message": map[string]any{
+				"type":      "string",
+				"maxLength": 2000,
+				"example":   "Thanks for working with us this quarter.",
+			},
+		},
+	},
+	"InvoiceEmail": {
+		"type": "object",
+		"properties": map[string]any{
+			"id":         map[string]any{"type": "integer", "example": 1},
+			"tenant_id":  map[string]any{"type": "integer", "example": 1},
+			"invoice_id": map[string]any{"type": "integer", "example": 1},
+			"kind": map[string]any{
+				"type": "string",
+				"enum": []string{"invoice", "reminder"},
+			},
+			"reminder_level": map[string]any{
+				"type":        "integer",
+				"example":     0,
+				"description": "Counts payment reminders from 1; 0 for the invoice itself",
+			},
+			"recipient": map[string]any{"type": "string", "example": "billing@customer.com"},
+			"subject":   map[string]any{"type": "string", "example": "Invoice #1001 from Acme"},
+			"status": map[string]any{
+				"type": "string",
+				"enum": []string{"sent", "failed"},
+			},
+			"error":      map[string]any{"type": "string"},
+			"message_id": map[string]any{"type": "string"},
+			"created_at": map[string]any{"type": "string", "format": "date-time"},
+		},
+	},
+	"InvoiceEmailResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string", "example": "Invoice sent successfully"},
+			"data":    map[string]any{"$ref": "#/definitions/InvoiceEmail"},
+		},
+	},
+	"InvoiceEmailListResponse": {
+		"type": "object",
+		"properties": map[string]any{
+			"data": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/definitions/InvoiceEmail"},
+			},
+		},
+	},
 }
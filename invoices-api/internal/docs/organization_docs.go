@@ -0,0 +1,45 @@
+package docs
+
+var OrganizationEndpoints = map[string]EndpointDoc{
+	"ListOrganizations": {
+		Summary:     "List organizations",
+		Description: "List every organization (tenant)",
+		Tags:        []string{"organizations"},
+		Method:      "GET",
+		Path:        "/v1/organizations",
+		Permission:  "organizations:manage",
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "OrganizationListResponse",
+			},
+		},
+	},
+	"CreateOrganization": {
+		Summary:     "Create an organization",
+		Description: "Create a new organization. Its data is isolated from every other organization",
+		Tags:        []string{"organizations"},
+		Method:      "POST",
+		Path:        "/v1/organizations",
+		Permission:  "organizations:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "organization",
+				In:          "body",
+				Required:    true,
+				Schema:      "CreateOrganizationRequest",
+				Description: "Organization name and slug",
+			},
+		},
+		Responses: map[int]Response{
+			201: {
+				Description: "Organization created",
+				Schema:      "OrganizationResponse",
+			},
+			400: {
+				Description: "Invalid name or slug, or slug already taken",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+}
@@ -0,0 +1,124 @@
+package docs
+
+var ReportEndpoints = map[string]EndpointDoc{
+	"GetSummary": {
+		Summary:     "Invoice summary report",
+		Description: "Totals, counts and averages grouped by status, service and period, computed over all invoices matching the filters",
+		Tags:        []string{"reports"},
+		Method:      "GET",
+		Path:        "/v1/reports/summary",
+		Permission:  "reports:read",
+		Parameters: []Parameter{
+			{
+				Name:        "from",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Start of the date range (YYYY-MM-DD or RFC3339, inclusive)",
+			},
+			{
+				Name:        "to",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "End of the date range (YYYY-MM-DD inclusive, RFC3339 exclusive)",
+			},
+			{
+				Name:        "period",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Default:     "month",
+				Description: "Period grouping (month/quarter/year)",
+			},
+			{
+				Name:        "search",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Search by service name",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "SummaryResponse",
+			},
+			400: {
+				Description: "Invalid filter",
+				Schema:      "ErrorResponse",
+			},
+			500: {
+				Description: "Internal server error",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"GetRevenue": {
+		Summary:     "Revenue time series",
+		Description: "Gap-filled revenue series per service and overall, with running totals, moving averages, change against the previous bucket and a comparison with the preceding period of equal length",
+		Tags:        []string{"reports"},
+		Method:      "GET",
+		Path:        "/v1/reports/revenue",
+		Permission:  "reports:read",
+		Parameters: []Parameter{
+			{
+				Name:        "interval",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Default:     "day",
+				Description: "Bucket size (day/week/month)",
+			},
+			{
+				Name:        "from",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Start of the series (YYYY-MM-DD or RFC3339). Defaults to 30 days, 12 weeks or 12 months before to",
+			},
+			{
+				Name:        "to",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "End of the series (YYYY-MM-DD inclusive, RFC3339 exclusive). Defaults to today",
+			},
+			{
+				Name:        "window",
+				In:          "query",
+				Type:        "integer",
+				Required:    false,
+				Description: "Moving average width in buckets. Defaults to 7 days, 4 weeks or 3 months",
+			},
+			{
+				Name:        "status",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Only count invoices with this status (Paid/Pending/Unpaid)",
+			},
+			{
+				Name:        "search",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Search by service name",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "RevenueResponse",
+			},
+			400: {
+				Description: "Invalid filter",
+				Schema:      "ErrorResponse",
+			},
+			500: {
+				Description: "Internal server error",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+}
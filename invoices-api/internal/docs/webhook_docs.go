@@ -0,0 +1,182 @@
+package docs
+
+var WebhookEndpoints = map[string]EndpointDoc{
+	"ListWebhooks": {
+		Summary:     "List webhooks",
+		Description: "List the webhook subscriptions of the organization. Secrets are never returned",
+		Tags:        []string{"webhooks"},
+		Method:      "GET",
+		Path:        "/v1/webhooks",
+		Permission:  "webhooks:manage",
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "WebhookListResponse",
+			},
+		},
+	},
+	"CreateWebhook": {
+		Summary:     "Create a webhook",
+		Description: "Subscribe a URL to invoice events. The signing secret is only returned in this response",
+		Tags:        []string{"webhooks"},
+		Method:      "POST",
+		Path:        "/v1/webhooks",
+		Permission:  "webhooks:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "webhook",
+				In:          "body",
+				Required:    true,
+				Schema:      "CreateWebhookRequest",
+				Description: "Target URL and events",
+			},
+		},
+		Responses: map[int]Response{
+			201: {
+				Description: "Webhook created",
+				Schema:      "CreatedWebhookResponse",
+			},
+			400: {
+				Description: "Invalid URL or event",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"GetWebhook": {
+		Summary:     "Get a webhook",
+		Description: "Get a webhook subscription by ID",
+		Tags:        []string{"webhooks"},
+		Method:      "GET",
+		Path:        "/v1/webhooks/{id}",
+		Permission:  "webhooks:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Webhook ID",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "WebhookResponse",
+			},
+			404: {
+				Description: "Webhook not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"DeleteWebhook": {
+		Summary:     "Delete a webhook",
+		Description: "Delete a webhook subscription together with its delivery log",
+		Tags:        []string{"webhooks"},
+		Method:      "DELETE",
+		Path:        "/v1/webhooks/{id}",
+		Permission:  "webhooks:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Webhook ID",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Webhook deleted",
+			},
+			404: {
+				Description: "Webhook not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"ListWebhookDeliveries": {
+		Summary:     "List webhook deliveries",
+		Description: "Get the delivery log of a webhook, newest first",
+		Tags:        []string{"webhooks"},
+		Method:      "GET",
+		Path:        "/v1/webhooks/{id}/deliveries",
+		Permission:  "webhooks:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Webhook ID",
+			},
+			{
+				Name:        "status",
+				In:          "query",
+				Type:        "string",
+				Required:    false,
+				Description: "Only deliveries with this status (pending, succeeded, failed)",
+			},
+			{
+				Name:        "page",
+				In:          "query",
+				Type:        "integer",
+				Required:    false,
+				Default:     "1",
+				Description: "Page number",
+			},
+			{
+				Name:        "limit",
+				In:          "query",
+				Type:        "integer",
+				Required:    false,
+				Default:     "10",
+				Description: "Items per page",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Successful response",
+				Schema:      "WebhookDeliveryListResponse",
+			},
+			404: {
+				Description: "Webhook not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+	"RetryWebhookDelivery": {
+		Summary:     "Retry a webhook delivery",
+		Description: "Queue a delivery again with a fresh set of attempts",
+		Tags:        []string{"webhooks"},
+		Method:      "POST",
+		Path:        "/v1/webhooks/{id}/deliveries/{deliveryId}/retry",
+		Permission:  "webhooks:manage",
+		Parameters: []Parameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Webhook ID",
+			},
+			{
+				Name:        "deliveryId",
+				In:          "path",
+				Type:        "integer",
+				Required:    true,
+				Description: "Delivery ID",
+			},
+		},
+		Responses: map[int]Response{
+			200: {
+				Description: "Delivery queued",
+				Schema:      "WebhookDeliveryResponse",
+			},
+			404: {
+				Description: "Delivery not found",
+				Schema:      "ErrorResponse",
+			},
+		},
+	},
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"log/slog"
+	"sync"
+)
+
+// Bus fans published events out to in-process subscribers. Sends never
+// block the feed: a subscriber whose buffer is full is unsubscribed and its
+// channel closed, so that it notices the gap and catches up from the outbox
+// instead of silently missing events.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[chan models.OutboxEvent]struct{}
+	closed      bool
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[chan models.OutboxEvent]struct{}),
+	}
+}
+
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+// Subscribe returns a channel of events and a function that cancels the
+// subscription and closes the channel. The bus also closes the channel when
+// more than buffer events are waiting in it.
+func (b *Bus) Subscribe(buffer int) (<-chan models.OutboxEvent, func()) {
+	ch := make(chan models.OutboxEvent, buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends all subscriptions, e.g. so that open streams finish on
+// shutdown. Later subscriptions are closed right away.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *Bus) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	var overflowed []chan models.OutboxEvent
+
+	b.mu.RLock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- *event:
+		default:
+			overflowed = append(overflowed, ch)
+		}
+	}
+	b.mu.RUnlock()
+
+	if len(overflowed) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range overflowed {
+		// The subscription may have been cancelled in the meantime.
+		if _, ok := b.subscribers[ch]; ok {
+			slog.WarnContext(ctx, "Event bus subscriber is full, closing it", "event_id", event.EventID)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return nil
+}
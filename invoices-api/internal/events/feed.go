@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"invoices-api/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// Feed passes the events published by the relay to the bus of this
+// instance. The relay runs on one instance only, so every instance follows
+// the published events in the outbox instead of relying on the relay to
+// reach its subscribers.
+type Feed struct {
+	repo         repository.OutboxRepository
+	bus          *Bus
+	pollInterval time.Duration
+}
+
+func NewFeed(repo repository.OutboxRepository, bus *Bus) *Feed {
+	return &Feed{
+		repo:         repo,
+		bus:          bus,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// Run passes events published from now on to the bus until ctx is
+// cancelled. Events published before it started are left to the stream
+// backlog.
+func (f *Feed) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	var position repository.OutboxPosition
+	started := false
+
+	for {
+		if !started {
+			var err error
+			if position, err = f.repo.LastPublished(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to start event feed", "error", err)
+			} else {
+				started = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !started {
+			continue
+		}
+		for {
+			n, err := f.forward(ctx, &position)
+			if err != nil {
+				slog.ErrorContext(ctx, "Event feed failed", "error", err)
+			}
+			if n < batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// forward publishes one batch of events after position to the bus, moves
+// position past them and returns how many there were.
+func (f *Feed) forward(ctx context.Context, position *repository.OutboxPosition) (int, error) {
+	events, err := f.repo.PublishedAfter(ctx, *position, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range events {
+		event := &events[i]
+		f.bus.Publish(ctx, event)
+		if event.PublishedAt != nil {
+			*position = repository.OutboxPosition{PublishedAt: *event.PublishedAt, ID: event.ID}
+		}
+	}
+	return len(events), nil
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"invoices-api/internal/models"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	invoicesCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "invoices_created_total",
+		Help: "Invoices created",
+	})
+	invoicesBilledAmountTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "invoices_billed_amount_total",
+		Help: "Sum of the amounts of the invoices created",
+	})
+	invoicesPaidTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "invoices_paid_total",
+		Help: "Invoices marked as paid",
+	})
+	invoicesPaidAmountTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "invoices_paid_amount_total",
+		Help: "Sum of the amounts of the invoices marked as paid",
+	})
+	invoicesDeletedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "invoices_deleted_total",
+		Help: "Invoices deleted",
+	})
+)
+
+type metricsSink struct{}
+
+// NewMetricsSink returns a sink that counts invoice events. Counting events
+// rather than requests includes batches, imports and the CLI, and leaves out
+// writes that were rolled back. An event published again after a failure is
+// counted again, so it should come after the sinks that can fail.
+func NewMetricsSink() Sink {
+	return metricsSink{}
+}
+
+func (metricsSink) Name() string {
+	return "metrics"
+}
+
+func (metricsSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	var invoice models.Invoice
+	if err := json.Unmarshal(event.Payload, &invoice); err != nil {
+		// Metrics are not worth retrying the event for.
+		slog.WarnContext(ctx, "Failed to decode event for metrics", "event_id", event.EventID, "error", err)
+		return nil
+	}
+
+	switch event.Type {
+	case models.EventInvoiceCreated:
+		invoicesCreatedTotal.Inc()
+		invoicesBilledAmountTotal.Add(max(invoice.Amount, 0))
+	case models.EventInvoicePaid:
+		invoicesPaidTotal.Inc()
+		invoicesPaidAmountTotal.Add(max(invoice.Amount, 0))
+	case models.EventInvoiceDeleted:
+		invoicesDeletedTotal.Inc()
+	}
+	return nil
+}
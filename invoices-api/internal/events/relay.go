@@ -0,0 +1,154 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultMaxAttempts  = 20
+	batchSize           = 100
+	relayTimeout        = time.Minute
+	cleanupInterval     = time.Hour
+
+	baseBackoff = time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	MaxAttempts  int
+	// Retention is how long published events are kept, e.g. for clients
+	// that resume a stream. Zero keeps them forever.
+	Retention time.Duration
+}
+
+// Relay publishes pending outbox events to its sinks. Only one relay runs at
+// a time across all instances, and it publishes the events of an aggregate
+// in the order they were written: when an event fails, later events of the
+// same invoice wait until it has been published or given up on.
+type Relay struct {
+	repo   repository.OutboxRepository
+	sinks  []Sink
+	config RelayConfig
+}
+
+func NewRelay(repo repository.OutboxRepository, sinks []Sink, config RelayConfig) *Relay {
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &Relay{
+		repo:   repo,
+		sinks:  sinks,
+		config: config,
+	}
+}
+
+// Run publishes events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for {
+				n, err := r.RelayPending(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "Outbox relay failed", "error", err)
+				}
+				if n < batchSize || ctx.Err() != nil {
+					break
+				}
+			}
+
+			if r.config.Retention > 0 && now.Sub(lastCleanup) >= cleanupInterval {
+				lastCleanup = now
+				if _, err := r.repo.DeletePublished(ctx, now.Add(-r.config.Retention)); err != nil {
+					slog.ErrorContext(ctx, "Failed to clean up outbox", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// RelayPending publishes one batch of due events and returns how many were
+// processed. It does nothing while another relay holds the lock.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, relayTimeout)
+	defer cancel()
+
+	processed := 0
+	_, err := r.repo.WithRelayLock(ctx, func(repo repository.OutboxRepository) error {
+		now := time.Now()
+		events, err := repo.Pending(ctx, now, batchSize)
+		if err != nil {
+			return err
+		}
+		processed = len(events)
+
+		blocked := make(map[string]bool)
+		var published []uint
+
+		for i := range events {
+			event := &events[i]
+			key := event.AggregateKey()
+			if blocked[key] {
+				continue
+			}
+
+			if err := r.publish(ctx, event); err != nil {
+				r.fail(event, err, now)
+				if event.Status != models.OutboxDead {
+					blocked[key] = true
+				}
+				if err := repo.MarkFailed(ctx, event); err != nil {
+					return err
+				}
+				continue
+			}
+
+			published = append(published, event.ID)
+		}
+
+		return repo.MarkPublished(ctx, published)
+	})
+
+	return processed, err
+}
+
+func (r *Relay) publish(ctx context.Context, event *models.OutboxEvent) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (r *Relay) fail(event *models.OutboxEvent, err error, now time.Time) {
+	event.Attempts++
+	event.LastError = err.Error()
+
+	if event.Attempts >= r.config.MaxAttempts {
+		event.Status = models.OutboxDead
+		slog.Error("Giving up on event", "event_id", event.EventID, "attempts", event.Attempts, "error", err)
+		return
+	}
+
+	event.NextAttemptAt = now.Add(min(baseBackoff<<min(event.Attempts-1, 20), maxBackoff))
+	slog.Warn("Failed to publish event", "event_id", event.EventID, "attempt", event.Attempts, "error", err)
+}
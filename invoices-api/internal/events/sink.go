@@ -0,0 +1,31 @@
+package events
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"log/slog"
+)
+
+// Sink receives published outbox events. Delivery is at least once: an
+// event is published again when any sink fails or the relay stops before
+// recording it, so sinks should tolerate duplicates, e.g. by event ID.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+type logSink struct{}
+
+// NewLogSink returns a sink that writes every event to the log.
+func NewLogSink() Sink {
+	return logSink{}
+}
+
+func (logSink) Name() string {
+	return "log"
+}
+
+func (logSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	slog.InfoContext(ctx, "Event", "event_id", event.EventID, "type", event.Type, "tenant_id", event.TenantID, "aggregate", event.AggregateKey())
+	return nil
+}
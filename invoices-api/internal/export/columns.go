@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"invoices-api/internal/models"
+	"strings"
+)
+
+type columnKind int
+
+const (
+	kindText columnKind = iota
+	kindInteger
+	kindAmount
+	kindDate
+	kindDateTime
+)
+
+type Column struct {
+	Key    string
+	Header string
+	kind   columnKind
+	value  func(invoice *models.Invoice) any
+}
+
+var AvailableColumns = []Column{
+	{Key: "id", Header: "ID", kind: kindInteger, value: func(i *models.Invoice) any { return i.ID }},
+	{Key: "invoice_number", Header: "Invoice Number", kind: kindInteger, value: func(i *models.Invoice) any { return i.InvoiceNumber }},
+	{Key: "service_name", Header: "Service Name", kind: kindText, value: func(i *models.Invoice) any { return i.ServiceName }},
+	{Key: "date", Header: "Date", kind: kindDate, value: func(i *models.Invoice) any { return i.Date }},
+	{Key: "amount", Header: "Amount", kind: kindAmount, value: func(i *models.Invoice) any { return i.Amount }},
+	{Key: "status", Header: "Status", kind: kindText, value: func(i *models.Invoice) any { return i.Status }},
+	{Key: "customer_email", Header: "Customer Email", kind: kindText, value: func(i *models.Invoice) any { return i.CustomerEmail }},
+	{Key: "due_date", Header: "Due Date", kind: kindDate, value: func(i *models.Invoice) any {
+		if i.DueDate == nil {
+			return nil
+		}
+		return *i.DueDate
+	}},
+	{Key: "created_at", Header: "Created At", kind: kindDateTime, value: func(i *models.Invoice) any { return i.CreatedAt }},
+	{Key: "updated_at", Header: "Updated At", kind: kindDateTime, value: func(i *models.Invoice) any { return i.UpdatedAt }},
+}
+
+var defaultColumns = []string{"invoice_number", "service_name", "date", "amount", "status", "customer_email", "due_date"}
+
+// ParseColumns resolves a comma separated list of column keys. An empty
+// spec selects the default column set.
+func ParseColumns(spec string) ([]Column, error) {
+	keys := defaultColumns
+	if strings.TrimSpace(spec) != "" {
+		keys = strings.Split(spec, ",")
+	}
+
+	columns := make([]Column, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+
+		column, ok := lookupColumn(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
+
+		seen[key] = true
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("at least one column must be selected")
+	}
+
+	return columns, nil
+}
+
+func ColumnKeys() []string {
+	keys := make([]string, 0, len(AvailableColumns))
+	for _, column := range AvailableColumns {
+		keys = append(keys, column.Key)
+	}
+	return keys
+}
+
+func lookupColumn(key string) (Column, bool) {
+	for _, column := range AvailableColumns {
+		if column.Key == key {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Locale struct {
+	Tag              string
+	DecimalSep       string
+	ThousandsSep     string
+	DateLayout       string
+	DateTimeLayout   string
+	CSVDelimiter     rune
+	ExcelDateFormat  string
+	ExcelDateTimeFmt string
+}
+
+const DefaultLocale = "en-US"
+
+var locales = map[string]Locale{
+	"en-US": {
+		Tag:              "en-US",
+		DecimalSep:       ".",
+		ThousandsSep:     ",",
+		DateLayout:       "01/02/2006",
+		DateTimeLayout:   "01/02/2006 15:04:05",
+		CSVDelimiter:     ',',
+		ExcelDateFormat:  "mm/dd/yyyy",
+		ExcelDateTimeFmt: "mm/dd/yyyy hh:mm:ss",
+	},
+	"en-GB": {
+		Tag:              "en-GB",
+		DecimalSep:       ".",
+		ThousandsSep:     ",",
+		DateLayout:       "02/01/2006",
+		DateTimeLayout:   "02/01/2006 15:04:05",
+		CSVDelimiter:     ',',
+		ExcelDateFormat:  "dd/mm/yyyy",
+		ExcelDateTimeFmt: "dd/mm/yyyy hh:mm:ss",
+	},
+	"de-DE": {
+		Tag:              "de-DE",
+		DecimalSep:       ",",
+		ThousandsSep:     ".",
+		DateLayout:       "02.01.2006",
+		DateTimeLayout:   "02.01.2006 15:04:05",
+		CSVDelimiter:     ';',
+		ExcelDateFormat:  "dd.mm.yyyy",
+		ExcelDateTimeFmt: "dd.mm.yyyy hh:mm:ss",
+	},
+	"fr-FR": {
+		Tag:              "fr-FR",
+		DecimalSep:       ",",
+		ThousandsSep:     " ",
+		DateLayout:       "02/01/2006",
+		DateTimeLayout:   "02/01/2006 15:04:05",
+		CSVDelimiter:     ';',
+		ExcelDateFormat:  "dd/mm/yyyy",
+		ExcelDateTimeFmt: "dd/mm/yyyy hh:mm:ss",
+	},
+	"tr-TR": {
+		Tag:              "tr-TR",
+		DecimalSep:       ",",
+		ThousandsSep:     ".",
+		DateLayout:       "02.01.2006",
+		DateTimeLayout:   "02.01.2006 15:04:05",
+		CSVDelimiter:     ';',
+		ExcelDateFormat:  "dd.mm.yyyy",
+		ExcelDateTimeFmt: "dd.mm.yyyy hh:mm:ss",
+	},
+	"iso": {
+		Tag:              "iso",
+		DecimalSep:       ".",
+		ThousandsSep:     "",
+		DateLayout:       "2006-01-02",
+		DateTimeLayout:   "2006-01-02T15:04:05Z07:00",
+		CSVDelimiter:     ',',
+		ExcelDateFormat:  "yyyy-mm-dd",
+		ExcelDateTimeFmt: "yyyy-mm-dd hh:mm:ss",
+	},
+}
+
+func LookupLocale(tag string) (Locale, error) {
+	if tag == "" {
+		tag = DefaultLocale
+	}
+
+	for key, locale := range locales {
+		if strings.EqualFold(key, tag) {
+			return locale, nil
+		}
+	}
+
+	return Locale{}, fmt.Errorf("unsupported locale %q", tag)
+}
+
+func (l Locale) FormatAmount(value float64) string {
+	negative := value < 0
+	formatted := strconv.FormatFloat(math.Abs(value), 'f', 2, 64)
+	integer, fraction, _ := strings.Cut(formatted, ".")
+
+	if l.ThousandsSep != "" && len(integer) > 3 {
+		var b strings.Builder
+		lead := len(integer) % 3
+		if lead > 0 {
+			b.WriteString(integer[:lead])
+		}
+		for i := lead; i < len(integer); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(l.ThousandsSep)
+			}
+			b.WriteString(integer[i : i+3])
+		}
+		integer = b.String()
+	}
+
+	result := integer + l.DecimalSep + fraction
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
+// ParseAmount is the inverse of FormatAmount.
+func (l Locale) ParseAmount(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if l.ThousandsSep != "" {
+		value = strings.ReplaceAll(value, l.ThousandsSep, "")
+	}
+	value = strings.Replace(value, l.DecimalSep, ".", 1)
+
+	return strconv.ParseFloat(value, 64)
+}
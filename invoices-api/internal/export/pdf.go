@@ -0,0 +1,75 @@
+package export
+
+import (
+	"fmt"
+	"invoices-api/internal/models"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDFDocument holds what goes on a rendered invoice besides the invoice
+// itself.
+type PDFDocument struct {
+	Issuer  string
+	DueDate time.Time
+	Locale  Locale
+}
+
+// WritePDF renders a single invoice as an A4 PDF document.
+func WritePDF(w io.Writer, invoice *models.Invoice, doc PDFDocument) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Invoice %d", invoice.InvoiceNumber), true)
+	pdf.SetCreator("invoices-api", true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+
+	// The core fonts use cp1252; the translator maps UTF-8 input onto it.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	if doc.Issuer != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(0, 6, tr(doc.Issuer), "", 1, "R", false, 0, "")
+		pdf.Ln(4)
+	}
+
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 12, fmt.Sprintf("Invoice #%d", invoice.InvoiceNumber), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	details := [][2]string{
+		{"Invoice date", invoice.Date.Format(doc.Locale.DateLayout)},
+		{"Due date", doc.DueDate.Format(doc.Locale.DateLayout)},
+		{"Status", invoice.Status},
+	}
+	if invoice.CustomerEmail != "" {
+		details = append(details, [2]string{"Billed to", invoice.CustomerEmail})
+	}
+
+	pdf.SetFont("Helvetica", "", 11)
+	for _, row := range details {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(40, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 7, tr(row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(10)
+
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(120, 9, "Service", "B", 0, "L", true, 0, "")
+	pdf.CellFormat(50, 9, "Amount", "B", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(120, 9, tr(invoice.ServiceName), "", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 9, doc.Locale.FormatAmount(invoice.Amount), "", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(120, 10, "Total", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 10, doc.Locale.FormatAmount(invoice.Amount), "T", 1, "R", false, 0, "")
+
+	return pdf.Output(w)
+}
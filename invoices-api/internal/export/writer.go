@@ -0,0 +1,240 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"invoices-api/internal/models"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+
+	sheetName = "Invoices"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatCSV, FormatXLSX:
+		return Format(value), nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", value)
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes invoices row by row so that exports never have to hold the
+// full result set in memory.
+type Writer interface {
+	WriteRow(invoice *models.Invoice) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer, columns []Column, locale Locale) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns, locale)
+	case FormatXLSX:
+		return newXLSXWriter(w, columns, locale)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	out     *csv.Writer
+	columns []Column
+	locale  Locale
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column, locale Locale) (*csvWriter, error) {
+	out := csv.NewWriter(w)
+	out.Comma = locale.CSVDelimiter
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
+	if err := out.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	return &csvWriter{
+		out:     out,
+		columns: columns,
+		locale:  locale,
+		record:  make([]string, len(columns)),
+	}, nil
+}
+
+func (w *csvWriter) WriteRow(invoice *models.Invoice) error {
+	for i, column := range w.columns {
+		w.record[i] = w.format(column, column.value(invoice))
+	}
+	return w.out.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.out.Flush()
+	return w.out.Error()
+}
+
+// format writes an empty field for a missing value, such as an invoice
+// without a due date.
+func (w *csvWriter) format(column Column, value any) string {
+	if value == nil {
+		return ""
+	}
+
+	switch column.kind {
+	case kindAmount:
+		return w.locale.FormatAmount(value.(float64))
+	case kindDate:
+		return value.(time.Time).Format(w.locale.DateLayout)
+	case kindDateTime:
+		return value.(time.Time).Format(w.locale.DateTimeLayout)
+	default:
+		return escapeFormula(fmt.Sprint(value))
+	}
+}
+
+// formulaPrefixes are the characters that make spreadsheet applications
+// evaluate a CSV field as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula prefixes text that spreadsheet applications would evaluate
+// as a formula, such as a service name of =HYPERLINK(...), with a quote. It
+// is only needed for CSV: XLSX string cells are never evaluated.
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// UnescapeFormula reverses escapeFormula, so that exported CSV files can be
+// imported again unchanged.
+func UnescapeFormula(text string) string {
+	if len(text) > 1 && text[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(text[1])) {
+		return text[1:]
+	}
+	return text
+}
+
+type xlsxWriter struct {
+	out     io.Writer
+	file    *excelize.File
+	stream  *excelize.StreamWriter
+	columns []Column
+	styles  map[columnKind]int
+	row     int
+}
+
+func newXLSXWriter(w io.Writer, columns []Column, locale Locale) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", sheetName); err != nil {
+		return nil, fmt.Errorf("failed to prepare worksheet: %w", err)
+	}
+
+	stream, err := file.NewStreamWriter(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream writer: %w", err)
+	}
+
+	writer := &xlsxWriter{
+		out:     w,
+		file:    file,
+		stream:  stream,
+		columns: columns,
+		styles:  make(map[columnKind]int),
+		row:     1,
+	}
+
+	if err := writer.registerStyles(locale); err != nil {
+		return nil, err
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: column.Header}
+	}
+	if err := writer.writeCells(header); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *xlsxWriter) registerStyles(locale Locale) error {
+	amountFormat := "#,##0.00"
+	formats := map[columnKind]*excelize.Style{
+		kindAmount:   {CustomNumFmt: &amountFormat},
+		kindDate:     {CustomNumFmt: &locale.ExcelDateFormat},
+		kindDateTime: {CustomNumFmt: &locale.ExcelDateTimeFmt},
+	}
+
+	for kind, style := range formats {
+		id, err := w.file.NewStyle(style)
+		if err != nil {
+			return fmt.Errorf("failed to create cell style: %w", err)
+		}
+		w.styles[kind] = id
+	}
+
+	return nil
+}
+
+func (w *xlsxWriter) WriteRow(invoice *models.Invoice) error {
+	cells := make([]any, len(w.columns))
+	for i, column := range w.columns {
+		cells[i] = excelize.Cell{StyleID: w.styles[column.kind], Value: column.value(invoice)}
+	}
+	return w.writeCells(cells)
+}
+
+func (w *xlsxWriter) writeCells(cells []any) error {
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+
+	if err := w.stream.SetRow(cell, cells); err != nil {
+		return fmt.Errorf("failed to write row %d: %w", w.row, err)
+	}
+
+	w.row++
+	return nil
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to flush worksheet: %w", err)
+	}
+
+	if err := w.file.Write(w.out); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package export
+
+import "testing"
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"Consulting":        "Consulting",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"- Consulting":      "'- Consulting",
+		"+1":                "'+1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"'quoted":           "'quoted",
+		"":                  "",
+	}
+	for text, want := range tests {
+		escaped := escapeFormula(text)
+		if escaped != want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", text, escaped, want)
+		}
+		if got := UnescapeFormula(escaped); got != text {
+			t.Errorf("UnescapeFormula(%q) = %q, want %q", escaped, got, text)
+		}
+	}
+}
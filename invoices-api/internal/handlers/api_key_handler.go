@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"invoices-api/internal/auth"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type apiKeyHandler struct {
+	service *auth.APIKeyService
+}
+
+func NewAPIKeyHandler(service *auth.APIKeyService) APIKeyHandler {
+	return &apiKeyHandler{
+		service: service,
+	}
+}
+
+func (h *apiKeyHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.UserContext(), requestTimeout)
+}
+
+func (h *apiKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	keys, err := h.service.List(ctx, middleware.GetPrincipal(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "API keys retrieved successfully",
+		"data":    keys,
+	})
+}
+
+func (h *apiKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	var req models.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+
+	key, err := h.service.Create(ctx, middleware.GetPrincipal(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "API key created successfully; store the key now, it is not shown again",
+		"data":    key,
+	})
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return middleware.NewBadRequestError("Invalid ID format")
+	}
+
+	if err := h.service.Revoke(ctx, middleware.GetPrincipal(c), uint(id)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "API key revoked successfully",
+	})
+}
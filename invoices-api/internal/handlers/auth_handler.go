@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"invoices-api/internal/auth"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type authHandler struct {
+	service *auth.Service
+	users   repository.UserRepository
+}
+
+func NewAuthHandler(service *auth.Service, users repository.UserRepository) AuthHandler {
+	return &authHandler{
+		service: service,
+		users:   users,
+	}
+}
+
+func (h *authHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.UserContext(), requestTimeout)
+}
+
+func (h *authHandler) Login(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	var req models.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || req.Password == "" {
+		return middleware.NewBadRequestError("Email and password are required")
+	}
+
+	tokens, err := h.service.Login(ctx, req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Login successful",
+		"data":    tokens,
+	})
+}
+
+func (h *authHandler) Refresh(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	var req models.RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+	if req.RefreshToken == "" {
+		return middleware.NewBadRequestError("Refresh token is required")
+	}
+
+	tokens, err := h.service.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Token refreshed",
+		"data":    tokens,
+	})
+}
+
+func (h *authHandler) Logout(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	var req models.RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+	if req.RefreshToken == "" {
+		return middleware.NewBadRequestError("Refresh token is required")
+	}
+
+	if err := h.service.Logout(ctx, req.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Logged out successfully",
+	})
+}
+
+func (h *authHandler) Me(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	principal := middleware.GetPrincipal(c)
+	if principal == nil {
+		return middleware.NewUnauthorizedError("Not authenticated")
+	}
+
+	user, err := h.users.GetByID(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "User retrieved successfully",
+		"data":    user,
+	})
+}
@@ -8,4 +8,48 @@ type InvoiceHandler interface {
 	CreateInvoice(c *fiber.Ctx) error
 	UpdateInvoice(c *fiber.Ctx) error
 	DeleteInvoice(c *fiber.Ctx) error
+	ExportInvoices(c *fiber.Ctx) error
+	ImportInvoices(c *fiber.Ctx) error
+	BatchInvoices(c *fiber.Ctx) error
+}
+
+type ReportHandler interface {
+	GetSummary(c *fiber.Ctx) error
+	GetRevenue(c *fiber.Ctx) error
+}
+
+type AuthHandler interface {
+	Login(c *fiber.Ctx) error
+	Refresh(c *fiber.Ctx) error
+	Logout(c *fiber.Ctx) error
+	Me(c *fiber.Ctx) error
+}
+
+type APIKeyHandler interface {
+	ListAPIKeys(c *fiber.Ctx) error
+	CreateAPIKey(c *fiber.Ctx) error
+	RevokeAPIKey(c *fiber.Ctx) error
+}
+
+type OrganizationHandler interface {
+	ListOrganizations(c *fiber.Ctx) error
+	CreateOrganization(c *fiber.Ctx) error
+}
+
+type WebhookHandler interface {
+	ListWebhooks(c *fiber.Ctx) error
+	GetWebhook(c *fiber.Ctx) error
+	CreateWebhook(c *fiber.Ctx) error
+	DeleteWebhook(c *fiber.Ctx) error
+	ListDeliveries(c *fiber.Ctx) error
+	RetryDelivery(c *fiber.Ctx) error
+}
+
+type InvoiceStreamHandler interface {
+	StreamInvoices(c *fiber.Ctx) error
+}
+
+type InvoiceEmailHandler interface {
+	SendInvoice(c *fiber.Ctx) error
+	ListInvoiceEmails(c *fiber.Ctx) error
 }
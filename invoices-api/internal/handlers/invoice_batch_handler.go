@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	batchModeAtomic     = "atomic"
+	batchModeBestEffort = "best_effort"
+
+	batchOpCreate = "create"
+	batchOpUpdate = "update"
+	batchOpPatch  = "patch"
+	batchOpDelete = "delete"
+
+	maxBatchOperations = 1000
+	batchTimeout       = 2 * time.Minute
+)
+
+type BatchRequest struct {
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation describes a single change. Data holds a full invoice for
+// create and update, and only the changed fields for patch.
+type BatchOperation struct {
+	Op   string          `json:"op"`
+	ID   uint            `json:"id,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type BatchResult struct {
+	Index  int                       `json:"index"`
+	Op     string                    `json:"op"`
+	ID     uint                      `json:"id,omitempty"`
+	Status int                       `json:"status"`
+	Data   *models.Invoice           `json:"data,omitempty"`
+	Error  *middleware.ErrorResponse `json:"error,omitempty"`
+}
+
+func (h *invoiceHandler) BatchInvoices(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), batchTimeout)
+	defer cancel()
+
+	var req BatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+
+	if req.Mode == "" {
+		req.Mode = batchModeAtomic
+	}
+	if req.Mode != batchModeAtomic && req.Mode != batchModeBestEffort {
+		return middleware.NewBadRequestError("Mode must be one of: atomic, best_effort")
+	}
+
+	if len(req.Operations) == 0 {
+		return middleware.NewBadRequestError("At least one operation is required")
+	}
+	if len(req.Operations) > maxBatchOperations {
+		return middleware.NewBadRequestError(fmt.Sprintf("A batch may contain at most %d operations", maxBatchOperations))
+	}
+
+	results := make([]BatchResult, len(req.Operations))
+
+	if req.Mode == batchModeAtomic {
+		err := h.repo.Transaction(ctx, func(repo repository.InvoiceRepository) error {
+			for i, op := range req.Operations {
+				results[i] = h.applyOperation(ctx, repo, i, op)
+				if results[i].Error != nil {
+					return results[i].Error
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return h.batchRollbackError(results, err)
+		}
+	} else {
+		for i, op := range req.Operations {
+			results[i] = h.applyOperation(ctx, h.repo, i, op)
+		}
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Error == nil {
+			succeeded++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Batch processed",
+		"data": fiber.Map{
+			"mode":      req.Mode,
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+			"results":   results,
+		},
+	})
+}
+
+func (h *invoiceHandler) applyOperation(ctx context.Context, repo repository.InvoiceRepository, index int, op BatchOperation) BatchResult {
+	result := BatchResult{
+		Index:  index,
+		Op:     op.Op,
+		ID:     op.ID,
+		Status: fiber.StatusOK,
+	}
+
+	var (
+		invoice *models.Invoice
+		err     error
+	)
+
+	switch op.Op {
+	case batchOpCreate:
+		invoice, err = h.batchCreate(ctx, repo, op)
+		result.Status = fiber.StatusCreated
+	case batchOpUpdate:
+		invoice, err = h.batchUpdate(ctx, repo, op)
+	case batchOpPatch:
+		invoice, err = h.batchPatch(ctx, repo, op)
+	case batchOpDelete:
+		err = h.batchDelete(ctx, repo, op)
+	default:
+		err = middleware.NewBadRequestError("Op must be one of: create, update, patch, delete")
+	}
+
+	if err != nil {
+		result.Error = toErrorResponse(err)
+		result.Status = result.Error.Code
+		return result
+	}
+
+	if invoice != nil {
+		result.ID = invoice.ID
+		result.Data = invoice
+	}
+
+	return result
+}
+
+func (h *invoiceHandler) batchCreate(ctx context.Context, repo repository.InvoiceRepository, op BatchOperation) (*models.Invoice, error) {
+	invoice := new(models.Invoice)
+	if err := json.Unmarshal(op.Data, invoice); err != nil {
+		return nil, middleware.NewBadRequestError("Invalid invoice data")
+	}
+	invoice.ID = 0
+
+	if errs := h.validator.ValidateInvoice(invoice); len(errs) > 0 {
+		return nil, middleware.NewBadRequestError("Validation failed", errs)
+	}
+
+	if err := h.checkStatusChange(ctx, repo, 0, invoice.Status); err != nil {
+		return nil, err
+	}
+
+	if err := repo.Create(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (h *invoiceHandler) batchUpdate(ctx context.Context, repo repository.InvoiceRepository, op BatchOperation) (*models.Invoice, error) {
+	if op.ID == 0 {
+		return nil, middleware.NewBadRequestError("ID is required")
+	}
+
+	invoice := new(models.Invoice)
+	if err := json.Unmarshal(op.Data, invoice); err != nil {
+		return nil, middleware.NewBadRequestError("Invalid invoice data")
+	}
+
+	if errs := h.validator.ValidateInvoice(invoice); len(errs) > 0 {
+		return nil, middleware.NewBadRequestError("Validation failed", errs)
+	}
+
+	if err := h.checkStatusChange(ctx, repo, op.ID, invoice.Status); err != nil {
+		return nil, err
+	}
+
+	invoice.ID = op.ID
+	if err := repo.Update(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (h *invoiceHandler) batchPatch(ctx context.Context, repo repository.InvoiceRepository, op BatchOperation) (*models.Invoice, error) {
+	if op.ID == 0 {
+		return nil, middleware.NewBadRequestError("ID is required")
+	}
+
+	var updates map[string]interface{}
+	if err := json.Unmarshal(op.Data, &updates); err != nil || len(updates) == 0 {
+		return nil, middleware.NewBadRequestError("Invalid patch data")
+	}
+
+	if errs := h.validator.ValidatePartialUpdate(updates); len(errs) > 0 {
+		return nil, middleware.NewBadRequestError("Validation failed", errs)
+	}
+
+	existing, err := repo.GetByID(ctx, op.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The patch is applied to a copy so that existing keeps the stored values.
+	invoice := *existing
+	if err := json.Unmarshal(op.Data, &invoice); err != nil {
+		return nil, middleware.NewBadRequestError("Invalid patch data")
+	}
+	invoice.ID = op.ID
+
+	if errs := h.validator.ValidateInvoice(&invoice); len(errs) > 0 {
+		return nil, middleware.NewBadRequestError("Validation failed", errs)
+	}
+
+	if err := h.checkStatusChange(ctx, repo, op.ID, invoice.Status); err != nil {
+		return nil, err
+	}
+
+	if err := repo.Update(ctx, &invoice); err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+func (h *invoiceHandler) batchDelete(ctx context.Context, repo repository.InvoiceRepository, op BatchOperation) error {
+	if op.ID == 0 {
+		return middleware.NewBadRequestError("ID is required")
+	}
+
+	if !h.policy.Can(middleware.PrincipalFromContext(ctx), middleware.PermInvoicesDelete) {
+		return middleware.NewForbiddenError("Insufficient permissions", fiber.Map{
+			"required": middleware.PermInvoicesDelete,
+		})
+	}
+
+	return repo.Delete(ctx, op.ID)
+}
+
+func (h *invoiceHandler) batchRollbackError(results []BatchResult, err error) error {
+	for _, result := range results {
+		if result.Error != nil {
+			return middleware.NewError(
+				result.Error.Code,
+				fmt.Sprintf("Batch rolled back: operation %d failed: %s", result.Index, result.Error.Message),
+				result,
+			)
+		}
+	}
+	return err
+}
+
+func toErrorResponse(err error) *middleware.ErrorResponse {
+	if e, ok := err.(*middleware.ErrorResponse); ok {
+		return e
+	}
+	return middleware.NewInternalError("Internal Server Error")
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"invoices-api/internal/mailer"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"net/mail"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxEmailMessageLength = 2000
+
+type invoiceEmailHandler struct {
+	invoices repository.InvoiceRepository
+	emails   repository.InvoiceEmailRepository
+	// mailer is nil when email delivery is not configured.
+	mailer *mailer.Service
+}
+
+func NewInvoiceEmailHandler(invoices repository.InvoiceRepository, emails repository.InvoiceEmailRepository, service *mailer.Service) InvoiceEmailHandler {
+	return &invoiceEmailHandler{
+		invoices: invoices,
+		emails:   emails,
+		mailer:   service,
+	}
+}
+
+func (h *invoiceEmailHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.UserContext(), requestTimeout)
+}
+
+func (h *invoiceEmailHandler) SendInvoice(c *fiber.Ctx) error {
+	if h.mailer == nil {
+		return middleware.NewError(fiber.StatusServiceUnavailable, "Email delivery is not configured")
+	}
+
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req models.SendInvoiceRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewBadRequestError("Invalid request body")
+		}
+	}
+
+	if req.To = strings.TrimSpace(req.To); req.To != "" {
+		if _, err := mail.ParseAddress(req.To); err != nil {
+			return middleware.NewBadRequestError("Invalid recipient email address")
+		}
+	}
+	if len(req.Message) > maxEmailMessageLength {
+		return middleware.NewBadRequestError("Message is too long", fiber.Map{"max_length": maxEmailMessageLength})
+	}
+
+	invoice, err := h.invoices.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	email, err := h.mailer.SendInvoice(ctx, invoice, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invoice sent successfully",
+		"data":    email,
+	})
+}
+
+func (h *invoiceEmailHandler) ListInvoiceEmails(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	// Looking the invoice up first turns another organization's invoice into
+	// a 404 rather than an empty list.
+	if _, err := h.invoices.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	emails, err := h.emails.ListByInvoice(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": emails,
+	})
+}
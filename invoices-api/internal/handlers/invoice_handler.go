@@ -1,15 +1,21 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"invoices-api/internal/export"
+	"invoices-api/internal/importer"
 	"invoices-api/internal/models"
 	"invoices-api/internal/repository"
 	"invoices-api/pkg/middleware"
 	"invoices-api/pkg/validator"
+	"io"
+	"log/slog"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,6 +26,7 @@ const (
 	defaultLimit   = 10
 	maxLimit       = 100
 	requestTimeout = 30 * time.Second
+	importTimeout  = 5 * time.Minute
 )
 
 type RequestParams struct {
@@ -33,66 +40,54 @@ type RequestParams struct {
 type invoiceHandler struct {
 	repo      repository.InvoiceRepository
 	validator *validator.InvoiceValidator
-	cache     struct {
-		sync.RWMutex
-		data sync.Map
-	}
+	importer  *importer.Importer
+	policy    *middleware.Policy
 }
 
-func NewInvoiceHandler(repo repository.InvoiceRepository, validator *validator.InvoiceValidator) InvoiceHandler {
+func NewInvoiceHandler(repo repository.InvoiceRepository, validator *validator.InvoiceValidator, policy *middleware.Policy) InvoiceHandler {
 	return &invoiceHandler{
 		repo:      repo,
 		validator: validator,
+		importer:  importer.NewImporter(repo, validator),
+		policy:    policy,
 	}
 }
 
 func (h *invoiceHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(c.Context(), requestTimeout)
+	return context.WithTimeout(c.UserContext(), requestTimeout)
 }
 
 func (h *invoiceHandler) GetInvoices(c *fiber.Ctx) error {
 	ctx, cancel := h.withTimeout(c)
 	defer cancel()
 
-	params := h.parseQueryParams(c)
-	cacheKey := h.buildCacheKey("invoices", params)
-
-	h.cache.RLock()
-	cached, ok := h.cache.data.Load(cacheKey)
-	h.cache.RUnlock()
-	if ok {
-		return c.JSON(cached)
+	params, err := h.parseQueryParams(c)
+	if err != nil {
+		return err
 	}
 
-	var (
-		invoices []models.Invoice
-		total    int64
-		err      error
-	)
+	var list *repository.InvoiceList
 
 	queryParams := repository.NewQueryParams(params.Page, params.Limit, params.SortBy, params.SortDir)
 
 	if params.Search != "" {
-		invoices, total, err = h.repo.Search(ctx, params.Search, queryParams)
+		list, err = h.repo.Search(ctx, params.Search, queryParams)
 	} else {
-		invoices, total, err = h.repo.GetAll(ctx, queryParams)
+		list, err = h.repo.GetAll(ctx, queryParams)
 	}
 
 	if err != nil {
 		return err
 	}
 
-	response := fiber.Map{
-		"data": invoices,
-		"meta": h.buildMetadata(total, params),
+	if h.listNotModified(c, params, list) {
+		return nil
 	}
 
-	h.cache.Lock()
-	h.cache.data.Store(cacheKey, response)
-	h.cache.Unlock()
-	go h.scheduleInvalidateCache(cacheKey, 30*time.Second)
-
-	return c.JSON(response)
+	return c.JSON(fiber.Map{
+		"data": list.Invoices,
+		"meta": h.buildMetadata(list.Total, params),
+	})
 }
 
 func (h *invoiceHandler) GetInvoiceByID(c *fiber.Ctx) error {
@@ -109,6 +104,10 @@ func (h *invoiceHandler) GetInvoiceByID(c *fiber.Ctx) error {
 		return err
 	}
 
+	if middleware.NotModified(c, middleware.WeakETag(invoice.TenantID, invoice.ID, invoice.UpdatedAt.UnixNano()), invoice.UpdatedAt) {
+		return nil
+	}
+
 	return c.JSON(fiber.Map{
 		"data": invoice,
 	})
@@ -127,11 +126,13 @@ func (h *invoiceHandler) CreateInvoice(c *fiber.Ctx) error {
 		return middleware.NewBadRequestError("Validation failed", errs)
 	}
 
-	if err := h.repo.Create(ctx, invoice); err != nil {
+	if err := h.checkStatusChange(ctx, h.repo, 0, invoice.Status); err != nil {
 		return err
 	}
 
-	h.invalidateListCache()
+	if err := h.repo.Create(ctx, invoice); err != nil {
+		return err
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "Invoice created successfully",
@@ -157,14 +158,15 @@ func (h *invoiceHandler) UpdateInvoice(c *fiber.Ctx) error {
 		return middleware.NewBadRequestError("Validation failed", errs)
 	}
 
+	if err := h.checkStatusChange(ctx, h.repo, id, invoice.Status); err != nil {
+		return err
+	}
+
 	invoice.ID = id
 	if err := h.repo.Update(ctx, invoice); err != nil {
 		return err
 	}
 
-	h.invalidateListCache()
-	h.cache.data.Delete(h.buildCacheKey("invoice", id))
-
 	return c.JSON(fiber.Map{
 		"message": "Invoice updated successfully",
 		"data":    invoice,
@@ -184,15 +186,177 @@ func (h *invoiceHandler) DeleteInvoice(c *fiber.Ctx) error {
 		return err
 	}
 
-	h.invalidateListCache()
-	h.cache.data.Delete(h.buildCacheKey("invoice", id))
-
 	return c.JSON(fiber.Map{
 		"message": "Invoice deleted successfully",
 	})
 }
 
-func (h *invoiceHandler) parseQueryParams(c *fiber.Ctx) *RequestParams {
+func (h *invoiceHandler) ExportInvoices(c *fiber.Ctx) error {
+	params, err := h.parseQueryParams(c)
+	if err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(strings.ToLower(c.Query("format", string(export.FormatCSV))))
+	if err != nil {
+		return middleware.NewBadRequestError(err.Error())
+	}
+
+	columns, err := export.ParseColumns(c.Query("columns"))
+	if err != nil {
+		return middleware.NewBadRequestError(err.Error(), fiber.Map{"available_columns": export.ColumnKeys()})
+	}
+
+	locale, err := export.LookupLocale(c.Query("locale"))
+	if err != nil {
+		return middleware.NewBadRequestError(err.Error())
+	}
+
+	filename := fmt.Sprintf("invoices-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	search := params.Search
+	queryParams := repository.NewQueryParams(params.Page, params.Limit, params.SortBy, params.SortDir)
+	ctx := c.UserContext()
+	conn := c.Context().Conn()
+
+	// The fiber context is released once the handler returns, so everything
+	// the stream needs is captured above. The status has been sent by the
+	// time the export can fail, so a failure closes the connection instead:
+	// the client then sees an incomplete chunked response rather than a
+	// truncated file that looks complete.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer, err := export.NewWriter(format, w, columns, locale)
+		if err == nil {
+			err = h.repo.Export(ctx, search, queryParams, writer.WriteRow)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err == nil {
+			return
+		}
+
+		slog.ErrorContext(ctx, "Invoice export aborted", "error", err)
+		w.Flush()
+		if err := conn.Close(); err != nil {
+			slog.WarnContext(ctx, "Failed to abort invoice export", "error", err)
+		}
+	})
+
+	return nil
+}
+
+func (h *invoiceHandler) ImportInvoices(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), importTimeout)
+	defer cancel()
+
+	source, format, err := h.importSource(c)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	locale, err := export.LookupLocale(c.Query("locale"))
+	if err != nil {
+		return middleware.NewBadRequestError(err.Error())
+	}
+
+	report, err := h.importer.Import(ctx, source, importer.Options{
+		Format:    format,
+		Locale:    locale,
+		DryRun:    c.QueryBool("dry_run", false),
+		BatchSize: c.QueryInt("batch_size", importer.DefaultBatchSize),
+		AllowPaid: h.policy.Can(middleware.GetPrincipal(c), middleware.PermInvoicesMarkPaid),
+	})
+	if err != nil {
+		if _, ok := err.(*middleware.ErrorResponse); ok {
+			return err
+		}
+		return middleware.NewBadRequestError("Failed to read import file", err.Error())
+	}
+
+	message := "Import completed"
+	if report.DryRun {
+		message = "Dry run completed, no invoices were written"
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    report,
+	})
+}
+
+// importSource accepts either a multipart upload in the "file" field or the
+// raw request body. The format comes from the format query parameter, the
+// file extension or the Content-Type, in that order.
+func (h *invoiceHandler) importSource(c *fiber.Ctx) (io.ReadCloser, importer.Format, error) {
+	var (
+		source   io.ReadCloser
+		filename string
+	)
+
+	if header, err := c.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", middleware.NewBadRequestError("Failed to open uploaded file")
+		}
+		source = file
+		filename = header.Filename
+	} else {
+		if len(c.Body()) == 0 {
+			return nil, "", middleware.NewBadRequestError("Request body is empty")
+		}
+		source = io.NopCloser(bytes.NewReader(c.Body()))
+	}
+
+	if value := c.Query("format"); value != "" {
+		format, err := importer.ParseFormat(value)
+		if err != nil {
+			source.Close()
+			return nil, "", middleware.NewBadRequestError(err.Error())
+		}
+		return source, format, nil
+	}
+
+	if ext := strings.TrimPrefix(filepath.Ext(filename), "."); ext != "" {
+		if format, err := importer.ParseFormat(ext); err == nil {
+			return source, format, nil
+		}
+	}
+
+	if format, ok := importer.FormatFromContentType(c.Get(fiber.HeaderContentType)); ok {
+		return source, format, nil
+	}
+
+	return source, importer.FormatCSV, nil
+}
+
+// checkStatusChange enforces the mark-paid permission. Setting an invoice to
+// Paid requires it unless the invoice is already Paid; id is 0 for new
+// invoices.
+func (h *invoiceHandler) checkStatusChange(ctx context.Context, repo repository.InvoiceRepository, id uint, status string) error {
+	if status != models.StatusPaid || h.policy.Can(middleware.PrincipalFromContext(ctx), middleware.PermInvoicesMarkPaid) {
+		return nil
+	}
+
+	if id != 0 {
+		existing, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if existing.Status == models.StatusPaid {
+			return nil
+		}
+	}
+
+	return middleware.NewForbiddenError("Insufficient permissions to mark invoices as paid", fiber.Map{
+		"required": middleware.PermInvoicesMarkPaid,
+	})
+}
+
+func (h *invoiceHandler) parseQueryParams(c *fiber.Ctx) (*RequestParams, error) {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	if page < 1 {
 		page = defaultPage
@@ -203,6 +367,11 @@ func (h *invoiceHandler) parseQueryParams(c *fiber.Ctx) *RequestParams {
 		limit = defaultLimit
 	}
 
+	sortBy := c.Query("sort_by", "")
+	if sortBy != "" && !repository.IsValidSortColumn(sortBy) {
+		return nil, middleware.NewBadRequestError("Invalid sort_by", fiber.Map{"sort_columns": repository.SortColumns()})
+	}
+
 	sortDir := c.Query("sort_dir", "asc")
 	if sortDir != "asc" && sortDir != "desc" {
 		sortDir = "asc"
@@ -212,9 +381,9 @@ func (h *invoiceHandler) parseQueryParams(c *fiber.Ctx) *RequestParams {
 		Page:    page,
 		Limit:   limit,
 		Search:  c.Query("search", ""),
-		SortBy:  c.Query("sort_by", ""),
+		SortBy:  sortBy,
 		SortDir: sortDir,
-	}
+	}, nil
 }
 
 func (h *invoiceHandler) parseID(c *fiber.Ctx) (uint, error) {
@@ -225,6 +394,22 @@ func (h *invoiceHandler) parseID(c *fiber.Ctx) (uint, error) {
 	return uint(id), nil
 }
 
+// listNotModified answers a conditional listing request. The ETag covers the
+// query, the total and the version of every invoice on the page, so it also
+// changes when an invoice leaves the page. Last-Modified is the time any
+// invoice of the organization last changed, since the newest invoice on the
+// page cannot tell that another one was deleted.
+func (h *invoiceHandler) listNotModified(c *fiber.Ctx, params *RequestParams, list *repository.InvoiceList) bool {
+	tenantID, _ := middleware.TenantFromContext(c.UserContext())
+	parts := []any{tenantID, params.Page, params.Limit, params.Search, params.SortBy, params.SortDir, list.Total}
+
+	for _, invoice := range list.Invoices {
+		parts = append(parts, invoice.ID, invoice.UpdatedAt.UnixNano())
+	}
+
+	return middleware.NotModified(c, middleware.WeakETag(parts...), list.ChangedAt)
+}
+
 func (h *invoiceHandler) buildMetadata(total int64, params *RequestParams) fiber.Map {
 	return fiber.Map{
 		"total":       total,
@@ -235,21 +420,3 @@ func (h *invoiceHandler) buildMetadata(total int64, params *RequestParams) fiber
 		"sort_dir":    params.SortDir,
 	}
 }
-
-func (h *invoiceHandler) buildCacheKey(prefix string, params interface{}) string {
-	return fmt.Sprintf("%s:%v", prefix, params)
-}
-
-func (h *invoiceHandler) invalidateListCache() {
-	h.cache.data.Range(func(key, value interface{}) bool {
-		if k, ok := key.(string); ok && strings.HasPrefix(k, "invoices:") {
-			h.cache.data.Delete(key)
-		}
-		return true
-	})
-}
-
-func (h *invoiceHandler) scheduleInvalidateCache(key interface{}, duration time.Duration) {
-	time.Sleep(duration)
-	h.cache.data.Delete(key)
-}
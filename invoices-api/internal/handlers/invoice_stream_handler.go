@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"invoices-api/internal/events"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	heartbeatInterval  = 15 * time.Second
+	streamBuffer       = 256
+	maxResumeEvents    = 1000
+	streamRetryMillis  = 5000
+	eventStreamReset   = "reset"
+	headerLastEventID  = "Last-Event-ID"
+	streamQueryTimeout = 10 * time.Second
+)
+
+type invoiceStreamHandler struct {
+	bus    *events.Bus
+	outbox repository.OutboxRepository
+}
+
+func NewInvoiceStreamHandler(bus *events.Bus, outbox repository.OutboxRepository) InvoiceStreamHandler {
+	return &invoiceStreamHandler{
+		bus:    bus,
+		outbox: outbox,
+	}
+}
+
+// streamFilter selects the events a client receives. Events of other
+// organizations are never sent.
+type streamFilter struct {
+	tenantID  uint
+	types     []string
+	invoiceID uint
+}
+
+func (f *streamFilter) matches(event *models.OutboxEvent) bool {
+	if event.TenantID != f.tenantID || event.AggregateType != models.AggregateInvoice {
+		return false
+	}
+	if len(f.types) > 0 && !slices.Contains(f.types, event.Type) {
+		return false
+	}
+	if f.invoiceID != 0 && event.AggregateID != f.invoiceID {
+		return false
+	}
+	return true
+}
+
+// StreamInvoices sends invoice events as Server-Sent Events. A client that
+// reconnects with Last-Event-ID first receives the events it missed, as far
+// as they are still kept in the outbox. When too many were missed, or the
+// event is no longer kept, it gets a "reset" event instead and should reload
+// its data. A client that falls behind is disconnected, so that it
+// reconnects and catches up the same way.
+func (h *invoiceStreamHandler) StreamInvoices(c *fiber.Ctx) error {
+	tenantID := middleware.GetTenant(c)
+	if tenantID == 0 {
+		return middleware.NewForbiddenError("No organization selected")
+	}
+
+	filter := &streamFilter{tenantID: tenantID}
+
+	if value := c.Query("events"); value != "" {
+		for _, event := range strings.Split(value, ",") {
+			event = strings.TrimSpace(event)
+			if !slices.Contains(models.WebhookEvents, event) {
+				return middleware.NewBadRequestError("Unknown event: "+event, fiber.Map{"available_events": models.WebhookEvents})
+			}
+			filter.types = append(filter.types, event)
+		}
+	}
+
+	if value := c.Query("invoice_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return middleware.NewBadRequestError("Invalid invoice_id")
+		}
+		filter.invoiceID = uint(id)
+	}
+
+	var lastEventID uint
+	if value := c.Get(headerLastEventID, c.Query("last_event_id")); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return middleware.NewBadRequestError("Invalid Last-Event-ID")
+		}
+		lastEventID = uint(id)
+	}
+
+	// Subscribing before reading the backlog means no event falls between
+	// the two; events seen in both are sent once.
+	ch, unsubscribe := h.bus.Subscribe(streamBuffer)
+
+	var backlog []models.OutboxEvent
+	reset := false
+	if lastEventID != 0 {
+		ctx, cancel := context.WithTimeout(c.UserContext(), streamQueryTimeout)
+		var err error
+		backlog, err = h.outbox.PublishedSince(ctx, lastEventID, maxResumeEvents+1)
+		cancel()
+
+		e, ok := err.(*middleware.ErrorResponse)
+		switch {
+		case ok && e.Code == fiber.StatusNotFound:
+			// The event is no longer kept, so what was missed is unknown.
+			reset = true
+		case err != nil:
+			unsubscribe()
+			return err
+		case len(backlog) > maxResumeEvents:
+			reset = true
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+
+		sent := make(map[uint]bool, len(backlog))
+		if reset {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventStreamReset)
+		} else {
+			for i := range backlog {
+				event := &backlog[i]
+				sent[event.ID] = true
+				if filter.matches(event) {
+					writeStreamEvent(w, event)
+				}
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				if sent[event.ID] || !filter.matches(&event) {
+					continue
+				}
+				writeStreamEvent(w, &event)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+
+			// A failed flush means the client went away.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeStreamEvent(w *bufio.Writer, event *models.OutboxEvent) {
+	data, err := json.Marshal(models.WebhookEvent{
+		ID:        event.EventID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		TenantID:  event.TenantID,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		slog.Error("Failed to encode stream event", "event_id", event.EventID, "error", err)
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type organizationHandler struct {
+	repo repository.OrganizationRepository
+}
+
+func NewOrganizationHandler(repo repository.OrganizationRepository) OrganizationHandler {
+	return &organizationHandler{
+		repo: repo,
+	}
+}
+
+func (h *organizationHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.UserContext(), requestTimeout)
+}
+
+func (h *organizationHandler) ListOrganizations(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	orgs, err := h.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Organizations retrieved successfully",
+		"data":    orgs,
+	})
+}
+
+func (h *organizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	var req models.CreateOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+
+	org := &models.Organization{
+		Name: strings.TrimSpace(req.Name),
+		Slug: strings.ToLower(strings.TrimSpace(req.Slug)),
+	}
+	if len(org.Name) < 2 {
+		return middleware.NewBadRequestError("Name must be at least 2 characters")
+	}
+	if !slugPattern.MatchString(org.Slug) {
+		return middleware.NewBadRequestError("Slug must contain only lowercase letters, digits and dashes")
+	}
+
+	if err := h.repo.Create(ctx, org); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Organization created successfully",
+		"data":    org,
+	})
+}
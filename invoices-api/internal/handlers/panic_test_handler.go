@@ -4,5 +4,4 @@ import "github.com/gofiber/fiber/v2"
 
 func PanicTestHandler(c *fiber.Ctx) error {
 	panic("Test panic!")
-	return nil
 }
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxRevenueBuckets = 1000
+
+// defaultRevenueRange is how far back a revenue series reaches when no from
+// date is given, and defaultWindow is the default moving average width.
+var (
+	defaultRevenueRange = map[string]func(time.Time) time.Time{
+		repository.IntervalDay:   func(t time.Time) time.Time { return t.AddDate(0, 0, -30) },
+		repository.IntervalWeek:  func(t time.Time) time.Time { return t.AddDate(0, 0, -7*12) },
+		repository.IntervalMonth: func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) },
+	}
+	defaultWindow = map[string]int{
+		repository.IntervalDay:   7,
+		repository.IntervalWeek:  4,
+		repository.IntervalMonth: 3,
+	}
+	bucketLength = map[string]time.Duration{
+		repository.IntervalDay:   24 * time.Hour,
+		repository.IntervalWeek:  7 * 24 * time.Hour,
+		repository.IntervalMonth: 28 * 24 * time.Hour,
+	}
+)
+
+type reportHandler struct {
+	repo repository.ReportRepository
+}
+
+func NewReportHandler(repo repository.ReportRepository) ReportHandler {
+	return &reportHandler{
+		repo: repo,
+	}
+}
+
+func (h *reportHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.UserContext(), requestTimeout)
+}
+
+func (h *reportHandler) GetSummary(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	filter, err := h.parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	filter.Period = c.Query("period", repository.PeriodMonth)
+	if !repository.IsValidPeriod(filter.Period) {
+		return middleware.NewBadRequestError("Period must be one of: month, quarter, year")
+	}
+
+	summary, err := h.repo.Summary(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": summary,
+	})
+}
+
+func (h *reportHandler) GetRevenue(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	interval := c.Query("interval", repository.IntervalDay)
+	if !repository.IsValidInterval(interval) {
+		return middleware.NewBadRequestError("Interval must be one of: day, week, month")
+	}
+
+	filter, err := h.parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	if filter.To.IsZero() {
+		filter.To = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if filter.From.IsZero() {
+		filter.From = defaultRevenueRange[interval](filter.To)
+	}
+	if !filter.From.Before(filter.To) {
+		return middleware.NewBadRequestError("The from date must be before the to date")
+	}
+
+	if filter.To.Sub(filter.From)/bucketLength[interval] > maxRevenueBuckets {
+		return middleware.NewBadRequestError(fmt.Sprintf("The date range spans more than %d %s buckets", maxRevenueBuckets, interval))
+	}
+
+	window := c.QueryInt("window", defaultWindow[interval])
+	if window < 1 || window > maxRevenueBuckets {
+		return middleware.NewBadRequestError("Window must be a positive number of buckets")
+	}
+
+	report, err := h.repo.Revenue(ctx, repository.RevenueFilter{
+		ReportFilter: filter,
+		Interval:     interval,
+		Status:       c.Query("status", ""),
+		Window:       window,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": report,
+	})
+}
+
+// parseReportFilter reads the list filters plus an optional date range.
+// Dates without a time component make "to" inclusive of that whole day.
+func (h *reportHandler) parseReportFilter(c *fiber.Ctx) (repository.ReportFilter, error) {
+	filter := repository.ReportFilter{
+		Search: c.Query("search", ""),
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseDateParam(value)
+		if err != nil {
+			return filter, middleware.NewBadRequestError("Invalid from date, expected YYYY-MM-DD or RFC3339")
+		}
+		filter.From = from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			return filter, middleware.NewBadRequestError("Invalid to date, expected YYYY-MM-DD or RFC3339")
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, middleware.NewBadRequestError("The from date must be before the to date")
+	}
+
+	return filter, nil
+}
+
+func parseDateParam(value string) (time.Time, bool, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, true, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	return date, false, err
+}
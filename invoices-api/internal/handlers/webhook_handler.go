@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/internal/webhooks"
+	"invoices-api/pkg/middleware"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type webhookHandler struct {
+	repo repository.WebhookRepository
+}
+
+func NewWebhookHandler(repo repository.WebhookRepository) WebhookHandler {
+	return &webhookHandler{
+		repo: repo,
+	}
+}
+
+func (h *webhookHandler) withTimeout(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.UserContext(), requestTimeout)
+}
+
+func (h *webhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	subs, err := h.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhooks retrieved successfully",
+		"data":    subs,
+	})
+}
+
+func (h *webhookHandler) GetWebhook(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	sub, err := h.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": sub,
+	})
+}
+
+func (h *webhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	var req models.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.NewBadRequestError("Invalid request body")
+	}
+
+	endpoint, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return middleware.NewBadRequestError("URL must be an absolute http or https URL")
+	}
+
+	if len(req.Events) == 0 {
+		return middleware.NewBadRequestError("At least one event is required", fiber.Map{"available_events": models.WebhookEvents})
+	}
+	for _, event := range req.Events {
+		if event != models.EventAll && !slices.Contains(models.WebhookEvents, event) {
+			return middleware.NewBadRequestError("Unknown event: "+event, fiber.Map{"available_events": models.WebhookEvents})
+		}
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return middleware.NewInternalError("Failed to generate webhook secret")
+	}
+
+	sub := models.WebhookSubscription{
+		URL:         endpoint.String(),
+		Description: strings.TrimSpace(req.Description),
+		Secret:      secret,
+		Events:      req.Events,
+		Active:      true,
+	}
+	if err := h.repo.CreateSubscription(ctx, &sub); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook created successfully; store the secret now, it is not shown again",
+		"data":    models.CreatedWebhook{WebhookSubscription: sub, Secret: secret},
+	})
+}
+
+func (h *webhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.repo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first.
+func (h *webhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.repo.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		return middleware.NewBadRequestError("Status must be one of: pending, succeeded, failed")
+	}
+
+	page := c.QueryInt("page", defaultPage)
+	if page < 1 {
+		page = defaultPage
+	}
+	limit := c.QueryInt("limit", defaultLimit)
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+
+	deliveries, total, err := h.repo.ListDeliveries(ctx, id, status, repository.NewQueryParams(page, limit, "", ""))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": deliveries,
+		"meta": fiber.Map{
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// RetryDelivery queues a delivery again, typically a failed one after the
+// receiver has been fixed.
+func (h *webhookHandler) RetryDelivery(c *fiber.Ctx) error {
+	ctx, cancel := h.withTimeout(c)
+	defer cancel()
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	deliveryID, err := parseUintParam(c, "deliveryId")
+	if err != nil {
+		return err
+	}
+
+	delivery, err := h.repo.RetryDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook delivery queued",
+		"data":    delivery,
+	})
+}
+
+func parseUintParam(c *fiber.Ctx, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Params(name), 10, 32)
+	if err != nil {
+		return 0, middleware.NewBadRequestError("Invalid ID format")
+	}
+	return uint(id), nil
+}
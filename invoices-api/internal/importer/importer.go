@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"invoices-api/internal/export"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/validator"
+	"io"
+	"sort"
+)
+
+const (
+	DefaultBatchSize = 500
+	MaxBatchSize     = 5000
+	// MaxReportedErrors bounds the report of a file with mostly bad rows.
+	MaxReportedErrors = 1000
+)
+
+type Options struct {
+	Format    Format
+	Locale    export.Locale
+	DryRun    bool
+	BatchSize int
+	// AllowPaid is false when the caller may not mark invoices as paid, in
+	// which case rows with status Paid are rejected.
+	AllowPaid bool
+}
+
+type RowError struct {
+	Row           int                         `json:"row"`
+	InvoiceNumber int                         `json:"invoice_number,omitempty"`
+	Errors        []validator.ValidationError `json:"errors"`
+}
+
+type Report struct {
+	DryRun    bool       `json:"dry_run"`
+	TotalRows int        `json:"total_rows"`
+	ValidRows int        `json:"valid_rows"`
+	Imported  int        `json:"imported"`
+	Failed    int        `json:"failed"`
+	Errors    []RowError `json:"errors"`
+	// ErrorsTruncated is set when more rows failed than are reported.
+	ErrorsTruncated bool `json:"errors_truncated,omitempty"`
+
+	batchFailed int
+}
+
+type Importer struct {
+	repo      repository.InvoiceRepository
+	validator *validator.InvoiceValidator
+}
+
+func NewImporter(repo repository.InvoiceRepository, validator *validator.InvoiceValidator) *Importer {
+	return &Importer{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// Import reads the rows in batches of opts.BatchSize. Rows that pass
+// validation and duplicate detection are committed batch by batch, each
+// batch in its own transaction, unless the import is a dry run. Only the
+// invoice numbers seen so far are kept for the whole file.
+func (i *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	if opts.BatchSize < 1 || opts.BatchSize > MaxBatchSize {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	rows, err := NewRowReader(opts.Format, r, opts.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		DryRun: opts.DryRun,
+		Errors: []RowError{},
+	}
+	firstSeen := make(map[int]int)
+	batch := make([]Row, 0, opts.BatchSize)
+
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		report.TotalRows++
+		if i.validateRow(&row, opts, firstSeen, report) {
+			batch = append(batch, row)
+		}
+
+		if len(batch) == opts.BatchSize {
+			if err := i.importBatch(ctx, batch, opts, report); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := i.importBatch(ctx, batch, opts, report); err != nil {
+		return nil, err
+	}
+
+	report.Failed = report.TotalRows - report.ValidRows + report.batchFailed
+	report.sortErrors()
+	return report, nil
+}
+
+// importBatch rejects the rows of batch whose invoice numbers are already
+// stored and creates the others.
+func (i *Importer) importBatch(ctx context.Context, batch []Row, opts Options, report *Report) error {
+	pending, err := i.rejectExisting(ctx, batch, report)
+	if err != nil {
+		return err
+	}
+
+	report.ValidRows += len(pending)
+	if opts.DryRun || len(pending) == 0 {
+		return nil
+	}
+
+	invoices := make([]*models.Invoice, len(pending))
+	for j := range pending {
+		invoices[j] = &pending[j].Invoice
+	}
+
+	if err := i.repo.CreateBatch(ctx, invoices); err != nil {
+		for _, row := range pending {
+			report.addError(row, "Batch", fmt.Sprintf("Batch rejected: %v", err))
+		}
+		report.batchFailed += len(pending)
+		return nil
+	}
+
+	report.Imported += len(pending)
+	return nil
+}
+
+// validateRow reports whether row can be imported, recording why not.
+func (i *Importer) validateRow(row *Row, opts Options, firstSeen map[int]int, report *Report) bool {
+	if row.ParseErr != nil {
+		report.addError(*row, "Row", row.ParseErr.Error())
+		return false
+	}
+
+	invoice := row.Invoice
+	invoice.ID = 0
+	if errs := i.validator.ValidateInvoice(&invoice); len(errs) > 0 {
+		report.add(RowError{
+			Row:           row.Line,
+			InvoiceNumber: invoice.InvoiceNumber,
+			Errors:        errs,
+		})
+		return false
+	}
+
+	if !opts.AllowPaid && invoice.Status == models.StatusPaid {
+		report.addError(*row, "Status", "Insufficient permissions to import paid invoices")
+		return false
+	}
+
+	if line, ok := firstSeen[invoice.InvoiceNumber]; ok {
+		report.addError(*row, "InvoiceNumber",
+			fmt.Sprintf("Invoice number %d is duplicated in the file (first seen on row %d)", invoice.InvoiceNumber, line))
+		return false
+	}
+	firstSeen[invoice.InvoiceNumber] = row.Line
+
+	row.Invoice = invoice
+	return true
+}
+
+// rejectExisting reports rows whose invoice number is already stored and
+// returns the remaining ones.
+func (i *Importer) rejectExisting(ctx context.Context, rows []Row, report *Report) ([]Row, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	numbers := make([]int, len(rows))
+	for j, row := range rows {
+		numbers[j] = row.Invoice.InvoiceNumber
+	}
+
+	existing, err := i.repo.ExistingInvoiceNumbers(ctx, numbers)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		number := row.Invoice.InvoiceNumber
+		if existing[number] {
+			report.addError(row, "InvoiceNumber", fmt.Sprintf("Invoice number %d already exists", number))
+			continue
+		}
+		remaining = append(remaining, row)
+	}
+
+	return remaining, nil
+}
+
+func (r *Report) addError(row Row, field, message string) {
+	r.add(RowError{
+		Row:           row.Line,
+		InvoiceNumber: row.Invoice.InvoiceNumber,
+		Errors: []validator.ValidationError{
+			{Field: field, Message: message},
+		},
+	})
+}
+
+// add records err unless MaxReportedErrors have been recorded already.
+func (r *Report) add(err RowError) {
+	if len(r.Errors) >= MaxReportedErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, err)
+}
+
+func (r *Report) sortErrors() {
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		return r.Errors[i].Row < r.Errors[j].Row
+	})
+}
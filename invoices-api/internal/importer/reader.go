@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"invoices-api/internal/export"
+	"invoices-api/internal/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(value) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported import format %q", value)
+	}
+}
+
+// FormatFromContentType maps a request Content-Type to an import format.
+func FormatFromContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv", "application/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatJSONL, true
+	default:
+		return "", false
+	}
+}
+
+// Row is a single parsed input record. Line refers to the physical line in
+// the source so that errors can be traced back by the uploader.
+type Row struct {
+	Line     int
+	Invoice  models.Invoice
+	ParseErr error
+}
+
+var requiredColumns = []string{"service_name", "invoice_number", "date", "amount", "status"}
+
+// RowReader reads the rows of an import one at a time, so that files of any
+// size are imported in constant memory.
+type RowReader interface {
+	// Next returns the next row, or io.EOF after the last one. Rows that
+	// cannot be parsed are returned with ParseErr set.
+	Next() (Row, error)
+}
+
+// NewRowReader reads the header, if the format has one, and returns a reader
+// of the remaining rows.
+func NewRowReader(format Format, r io.Reader, locale export.Locale) (RowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r, locale)
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	index  map[string]int
+	locale export.Locale
+}
+
+func newCSVReader(r io.Reader, locale export.Locale) (*csvReader, error) {
+	buffered := bufio.NewReader(r)
+	headerLine, err := buffered.Peek(4096)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = detectDelimiter(headerLine)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("csv file is empty")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[normalizeColumn(name)] = i
+	}
+
+	var missing []string
+	for _, column := range requiredColumns {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("csv header is missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	return &csvReader{reader: reader, index: index, locale: locale}, nil
+}
+
+func (c *csvReader) Next() (Row, error) {
+	for {
+		record, err := c.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return Row{}, io.EOF
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return Row{Line: parseErr.StartLine, ParseErr: parseErr.Err}, nil
+			}
+			return Row{}, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		if isBlank(record) {
+			continue
+		}
+
+		line, _ := c.reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := c.index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := Row{Line: line}
+		row.Invoice, row.ParseErr = parseCSVInvoice(field, c.locale)
+		return row, nil
+	}
+}
+
+func parseCSVInvoice(field func(string) string, locale export.Locale) (models.Invoice, error) {
+	// Text fields may carry the quote the export puts before formulas.
+	invoice := models.Invoice{
+		ServiceName:   export.UnescapeFormula(field("service_name")),
+		Status:        export.UnescapeFormula(field("status")),
+		CustomerEmail: export.UnescapeFormula(field("customer_email")),
+	}
+
+	if value := field("invoice_number"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return invoice, fmt.Errorf("invalid invoice_number %q", value)
+		}
+		invoice.InvoiceNumber = number
+	}
+
+	if value := field("amount"); value != "" {
+		amount, err := locale.ParseAmount(value)
+		if err != nil {
+			return invoice, fmt.Errorf("invalid amount %q", value)
+		}
+		invoice.Amount = amount
+	}
+
+	if value := field("date"); value != "" {
+		date, err := parseDate(value, locale)
+		if err != nil {
+			return invoice, err
+		}
+		invoice.Date = date
+	}
+
+	if value := field("due_date"); value != "" {
+		dueDate, err := parseDate(value, locale)
+		if err != nil {
+			return invoice, fmt.Errorf("invalid due_date %q", value)
+		}
+		invoice.DueDate = &dueDate
+	}
+
+	return invoice, nil
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &jsonlReader{scanner: scanner}
+}
+
+func (j *jsonlReader) Next() (Row, error) {
+	for j.scanner.Scan() {
+		j.line++
+		data := bytes.TrimSpace(j.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := Row{Line: j.line}
+		if err := json.Unmarshal(data, &row.Invoice); err != nil {
+			row.ParseErr = fmt.Errorf("invalid JSON: %v", err)
+		}
+		return row, nil
+	}
+
+	if err := j.scanner.Err(); err != nil {
+		return Row{}, fmt.Errorf("failed to read json lines: %w", err)
+	}
+	return Row{}, io.EOF
+}
+
+func parseDate(value string, locale export.Locale) (time.Time, error) {
+	layouts := []string{time.RFC3339, "2006-01-02", locale.DateLayout, locale.DateTimeLayout}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func detectDelimiter(sample []byte) rune {
+	firstLine, _, _ := bytes.Cut(sample, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// normalizeColumn accepts both API field names and the headers produced by
+// the export endpoint ("Invoice Number" -> "invoice_number").
+func normalizeColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDunningInterval = time.Hour
+	dunningBatchSize       = 100
+
+	// A reminder that could not be sent is retried after a day rather than
+	// on every run.
+	failedReminderRetry = 24 * time.Hour
+)
+
+type DunningConfig struct {
+	Interval time.Duration
+	// Schedule[n] is how long after the due date reminder n+1 is sent.
+	Schedule []time.Duration
+}
+
+// Dunning sends payment reminders for overdue invoices of every
+// organization. Only one instance sends reminders at a time.
+type Dunning struct {
+	service *Service
+	emails  repository.InvoiceEmailRepository
+	config  DunningConfig
+}
+
+func NewDunning(service *Service, emails repository.InvoiceEmailRepository, config DunningConfig) *Dunning {
+	if config.Interval <= 0 {
+		config.Interval = defaultDunningInterval
+	}
+
+	return &Dunning{
+		service: service,
+		emails:  emails,
+		config:  config,
+	}
+}
+
+// Run sends due reminders until ctx is cancelled.
+func (d *Dunning) Run(ctx context.Context) {
+	if len(d.config.Schedule) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sent, err := d.SendDue(ctx, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to send payment reminders", "error", err)
+			} else if sent > 0 {
+				slog.InfoContext(ctx, "Sent payment reminders", "count", sent)
+			}
+		}
+	}
+}
+
+// SendDue sends the reminders due at now and returns how many went out.
+func (d *Dunning) SendDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	_, err := d.emails.WithDunningLock(ctx, func() error {
+		for ctx.Err() == nil {
+			candidates, err := d.emails.DueReminders(ctx, repository.ReminderQuery{
+				Now:              now,
+				Terms:            d.service.config.PaymentTerms,
+				Schedule:         d.config.Schedule,
+				RetryFailedAfter: failedReminderRetry,
+				Limit:            dunningBatchSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			// Failures are recorded in the send log, which keeps the invoice
+			// out of the next batch until the retry delay. An invoice whose
+			// attempt could not even be recorded would come back, so a batch
+			// without any recorded attempt ends the run.
+			recorded := 0
+			for i := range candidates {
+				candidate := &candidates[i]
+				tenantCtx := middleware.WithTenant(ctx, candidate.TenantID)
+				entry, err := d.service.SendReminder(tenantCtx, &candidate.Invoice, candidate.RemindersSent+1)
+				if entry != nil {
+					recorded++
+				}
+				if err == nil {
+					sent++
+				}
+			}
+
+			if len(candidates) < dunningBatchSize || recorded == 0 {
+				return nil
+			}
+		}
+		return ctx.Err()
+	})
+
+	return sent, err
+}
+
+// ParseSchedule parses a comma separated list of offsets such as
+// "7d,14d,30d". Offsets accept a "d" suffix for days besides the units of
+// time.ParseDuration and must be increasing.
+func ParseSchedule(spec string) ([]time.Duration, error) {
+	var schedule []time.Duration
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || part == "off" {
+			continue
+		}
+
+		var offset time.Duration
+		if days, ok := strings.CutSuffix(part, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil {
+				return nil, fmt.Errorf("invalid reminder offset %q", part)
+			}
+			offset = time.Duration(n) * 24 * time.Hour
+		} else {
+			var err error
+			if offset, err = time.ParseDuration(part); err != nil {
+				return nil, fmt.Errorf("invalid reminder offset %q", part)
+			}
+		}
+
+		if offset < 0 || (len(schedule) > 0 && offset <= schedule[len(schedule)-1]) {
+			return nil, fmt.Errorf("reminder offsets must be increasing and not negative: %q", spec)
+		}
+		schedule = append(schedule, offset)
+	}
+
+	return schedule, nil
+}
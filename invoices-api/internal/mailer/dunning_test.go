@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	const day = 24 * time.Hour
+
+	tests := []struct {
+		spec string
+		want []time.Duration
+	}{
+		{"", nil},
+		{"off", nil},
+		{"7d,14d,30d", []time.Duration{7 * day, 14 * day, 30 * day}},
+		{" 0d , 36h, 3d ", []time.Duration{0, 36 * time.Hour, 3 * day}},
+		{"90m", []time.Duration{90 * time.Minute}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseSchedule(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"7x", "d", "1.5d", "-1d", "14d,7d", "7d,7d", "-1h"} {
+		if got, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) = %v, want an error", spec, got)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"invoices-api/internal/export"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"invoices-api/pkg/middleware"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const defaultPaymentTerms = 30 * 24 * time.Hour
+
+type Config struct {
+	From string
+	// Issuer is printed on the PDF and signs the emails.
+	Issuer string
+	// PaymentTerms is the time from the invoice date to its due date when
+	// the invoice has no due date of its own.
+	PaymentTerms time.Duration
+	Locale       export.Locale
+}
+
+// Service renders invoice emails, sends them with the PDF attached and
+// records every attempt in the invoice's send log.
+type Service struct {
+	sender    Sender
+	templates *Templates
+	emails    repository.InvoiceEmailRepository
+	config    Config
+}
+
+func NewService(sender Sender, templates *Templates, emails repository.InvoiceEmailRepository, config Config) *Service {
+	if config.PaymentTerms <= 0 {
+		config.PaymentTerms = defaultPaymentTerms
+	}
+
+	return &Service{
+		sender:    sender,
+		templates: templates,
+		emails:    emails,
+		config:    config,
+	}
+}
+
+// DueDate returns when the invoice has to be paid.
+func (s *Service) DueDate(invoice *models.Invoice) time.Time {
+	if invoice.DueDate != nil {
+		return *invoice.DueDate
+	}
+	return invoice.Date.Add(s.config.PaymentTerms)
+}
+
+// SendInvoice emails the invoice to req.To, or to its customer email when
+// req.To is empty.
+func (s *Service) SendInvoice(ctx context.Context, invoice *models.Invoice, req models.SendInvoiceRequest) (*models.InvoiceEmail, error) {
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		to = invoice.CustomerEmail
+	}
+	if to == "" {
+		return nil, middleware.NewBadRequestError("Invoice has no customer email; provide a recipient")
+	}
+
+	return s.send(ctx, invoice, models.EmailKindInvoice, 0, to, TemplateData{
+		Message: strings.TrimSpace(req.Message),
+	})
+}
+
+// SendReminder sends payment reminder number level for an overdue invoice.
+func (s *Service) SendReminder(ctx context.Context, invoice *models.Invoice, level int) (*models.InvoiceEmail, error) {
+	overdue := time.Since(s.DueDate(invoice))
+	return s.send(ctx, invoice, models.EmailKindReminder, level, invoice.CustomerEmail, TemplateData{
+		ReminderLevel: level,
+		DaysOverdue:   max(int(overdue.Hours()/24), 0),
+	})
+}
+
+func (s *Service) send(ctx context.Context, invoice *models.Invoice, kind string, level int, to string, data TemplateData) (*models.InvoiceEmail, error) {
+	dueDate := s.DueDate(invoice)
+
+	data.Invoice = invoice
+	data.Issuer = s.config.Issuer
+	data.Amount = s.config.Locale.FormatAmount(invoice.Amount)
+	data.Date = invoice.Date.Format(s.config.Locale.DateLayout)
+	data.DueDate = dueDate.Format(s.config.Locale.DateLayout)
+
+	subject, text, html, err := s.templates.Render(kind, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to render email", "kind", kind, "invoice_id", invoice.ID, "error", err)
+		return nil, middleware.NewInternalError("Failed to render email")
+	}
+
+	var pdf bytes.Buffer
+	if err := export.WritePDF(&pdf, invoice, export.PDFDocument{
+		Issuer:  s.config.Issuer,
+		DueDate: dueDate,
+		Locale:  s.config.Locale,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to render invoice PDF", "invoice_id", invoice.ID, "error", err)
+		return nil, middleware.NewInternalError("Failed to render invoice PDF")
+	}
+
+	msg := &Message{
+		From:    s.config.From,
+		To:      []string{to},
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+		Attachments: []Attachment{{
+			Filename:    fmt.Sprintf("invoice-%d.pdf", invoice.InvoiceNumber),
+			ContentType: "application/pdf",
+			Data:        pdf.Bytes(),
+		}},
+	}
+
+	entry := &models.InvoiceEmail{
+		InvoiceID:     invoice.ID,
+		Kind:          kind,
+		ReminderLevel: level,
+		Recipient:     to,
+		Subject:       subject,
+		Status:        models.EmailSent,
+	}
+
+	sendErr := s.sender.Send(ctx, msg)
+	entry.MessageID = msg.MessageID
+	if sendErr != nil {
+		slog.WarnContext(ctx, "Failed to send email", "kind", kind, "invoice_id", invoice.ID, "error", sendErr)
+		entry.Status = models.EmailFailed
+		entry.Error = sendErr.Error()
+	}
+
+	// The send log ignores cancellation so that a client disconnecting after
+	// the email went out does not lose the record.
+	if err := s.emails.Create(context.WithoutCancel(ctx), entry); err != nil {
+		return nil, err
+	}
+
+	if sendErr != nil {
+		return entry, middleware.NewError(fiber.StatusBadGateway, "Failed to send email", entry)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,195 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts one connection and records what the client sends.
+type fakeSMTPServer struct {
+	host, port string
+	// extensions are announced in reply to EHLO.
+	extensions []string
+
+	done     chan struct{}
+	auth     string
+	from     string
+	rcpt     []string
+	data     string
+	commands []string
+}
+
+func newFakeSMTPServer(t *testing.T, extensions ...string) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	s := &fakeSMTPServer{host: host, port: port, extensions: extensions, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		s.serve(bufio.NewReader(conn), conn)
+	}()
+
+	return s
+}
+
+func (s *fakeSMTPServer) serve(r *bufio.Reader, w net.Conn) {
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			w.Write([]byte(line + "\r\n"))
+		}
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		s.commands = append(s.commands, strings.ToUpper(verb))
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"250-localhost"}
+			for _, ext := range s.extensions {
+				lines = append(lines, "250-"+ext)
+			}
+			reply(append(lines, "250 8BITMIME")...)
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			s.auth = string(decoded)
+			reply("235 Authenticated")
+		case "MAIL":
+			s.from = arg
+			reply("250 OK")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, arg)
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.data = data.String()
+			reply("250 Queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) config(tls string) SMTPConfig {
+	port, _ := strconv.Atoi(s.port)
+	return SMTPConfig{Host: s.host, Port: port, TLS: tls, Timeout: 5 * time.Second}
+}
+
+func (s *fakeSMTPServer) wait(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP conversation did not finish")
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		From:    "Billing <billing@example.com>",
+		To:      []string{"Customer <customer@example.org>", "accounts@example.org"},
+		Subject: "Invoice 42",
+		Text:    "Please find your invoice attached.",
+	}
+}
+
+func TestSMTPSenderSendsMessage(t *testing.T) {
+	server := newFakeSMTPServer(t, "AUTH PLAIN")
+	config := server.config(TLSNone)
+	config.Username = "mailer"
+	config.Password = "secret"
+
+	sender, err := NewSMTPSender(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	server.wait(t)
+
+	if server.auth != "\x00mailer\x00secret" {
+		t.Errorf("authenticated with %q", server.auth)
+	}
+	if !strings.HasPrefix(server.from, "FROM:<billing@example.com>") {
+		t.Errorf("MAIL %s, want the sender address", server.from)
+	}
+	if len(server.rcpt) != 2 || !strings.HasPrefix(server.rcpt[0], "TO:<customer@example.org>") || !strings.HasPrefix(server.rcpt[1], "TO:<accounts@example.org>") {
+		t.Errorf("RCPT %v, want both recipient addresses", server.rcpt)
+	}
+	for _, want := range []string{"Subject: Invoice 42\r\n", "Please find your invoice attached."} {
+		if !strings.Contains(server.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, server.data)
+		}
+	}
+	if last := server.commands[len(server.commands)-1]; last != "QUIT" {
+		t.Errorf("conversation ended with %s, want QUIT", last)
+	}
+}
+
+func TestSMTPSenderRequiresStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	sender, err := NewSMTPSender(server.config(TLSStartTLS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sender.Send(context.Background(), testMessage())
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("got %v, want an error about STARTTLS", err)
+	}
+	server.wait(t)
+	for _, command := range server.commands {
+		if command == "MAIL" || command == "DATA" {
+			t.Fatalf("sent %s without TLS", command)
+		}
+	}
+}
+
+func TestNewSMTPSenderRejectsUnknownTLSMode(t *testing.T) {
+	if _, err := NewSMTPSender(SMTPConfig{Host: "localhost", TLS: "ssl"}); err == nil {
+		t.Fatal("accepted an unknown TLS mode")
+	}
+}
@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"invoices-api/internal/models"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Every kind of email has a subject, a plain text and an HTML template named
+// "<kind>.subject.tmpl", "<kind>.txt.tmpl" and "<kind>.html.tmpl".
+var templateKinds = []string{models.EmailKindInvoice, models.EmailKindReminder}
+
+// TemplateData is what the email templates are executed with.
+type TemplateData struct {
+	Invoice       *models.Invoice
+	Issuer        string
+	Amount        string
+	Date          string
+	DueDate       string
+	Message       string
+	ReminderLevel int
+	DaysOverdue   int
+}
+
+type Templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadTemplates parses the built-in templates. A template file of the same
+// name in dir, when given, replaces the built-in one.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{
+		text: texttemplate.New(""),
+		html: htmltemplate.New(""),
+	}
+
+	for _, kind := range templateKinds {
+		for _, suffix := range []string{"subject", "txt", "html"} {
+			name := kind + "." + suffix + ".tmpl"
+			source, err := readTemplate(dir, name)
+			if err != nil {
+				return nil, err
+			}
+
+			if suffix == "html" {
+				_, err = t.html.New(name).Parse(source)
+			} else {
+				_, err = t.text.New(name).Parse(source)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+
+	data, err := defaultTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// Render executes the templates of an email kind.
+func (t *Templates) Render(kind string, data TemplateData) (subject, text, html string, err error) {
+	var buf bytes.Buffer
+	if err := t.text.ExecuteTemplate(&buf, kind+".subject.tmpl", data); err != nil {
+		return "", "", "", err
+	}
+	// Subjects are single line; stray newlines would break the header.
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.text.ExecuteTemplate(&buf, kind+".txt.tmpl", data); err != nil {
+		return "", "", "", err
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err := t.html.ExecuteTemplate(&buf, kind+".html.tmpl", data); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// APIKey is a long-lived credential for machine clients. Only the SHA-256 of
+// the key is stored; Prefix is kept in clear so keys can be recognised in
+// listings.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey;column:id"`
+	TenantID   uint       `json:"tenant_id" gorm:"column:tenant_id;not null;index"`
+	UserID     uint       `json:"user_id" gorm:"column:user_id;not null;index"`
+	Name       string     `json:"name" gorm:"column:name;not null"`
+	Prefix     string     `json:"prefix" gorm:"column:prefix;not null"`
+	KeyHash    string     `json:"-" gorm:"column:key_hash;not null;uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"column:scopes;type:text;serializer:json;not null"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"column:expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatedAPIKey is returned once on creation; Key is not retrievable later.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the response to a mutating request so that a
+// retry with the same Idempotency-Key header is answered without running the
+// request again. Key combines the client and the header value.
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primaryKey;column:id"`
+	TenantID    uint      `gorm:"column:tenant_id;not null;uniqueIndex:idx_idempotency_keys_tenant_key,priority:1"`
+	Key         string    `gorm:"column:key;not null;uniqueIndex:idx_idempotency_keys_tenant_key,priority:2"`
+	Fingerprint string    `gorm:"column:fingerprint;not null"`
+	Completed   bool      `gorm:"column:completed;not null;default:false"`
+	StatusCode  int       `gorm:"column:status_code"`
+	ContentType string    `gorm:"column:content_type"`
+	Body        []byte    `gorm:"column:body;type:bytea"`
+	ExpiresAt   time.Time `gorm:"column:expires_at;not null;index"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -2,15 +2,22 @@ package models
 
 import "time"
 
+const StatusPaid = "Paid"
+
+// Invoice is sent to CustomerEmail when set. DueDate defaults to Date plus
+// the configured payment terms.
 type Invoice struct {
-	ID            uint      `json:"id" gorm:"primaryKey;column:id"`
-	ServiceName   string    `json:"service_name" gorm:"column:service_name;not null"`
-	InvoiceNumber int       `json:"invoice_number" gorm:"column:invoice_number;unique"`
-	Date          time.Time `json:"date" gorm:"column:date"`
-	Amount        float64   `json:"amount" gorm:"column:amount"`
-	Status        string    `json:"status" gorm:"column:status"`
-	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+	ID            uint       `json:"id" gorm:"primaryKey;column:id"`
+	TenantID      uint       `json:"tenant_id" gorm:"column:tenant_id;not null;uniqueIndex:idx_invoices_tenant_invoice_number,priority:1"`
+	ServiceName   string     `json:"service_name" gorm:"column:service_name;not null" validate:"required,min=2"`
+	InvoiceNumber int        `json:"invoice_number" gorm:"column:invoice_number;uniqueIndex:idx_invoices_tenant_invoice_number,priority:2" validate:"required,gt=0"`
+	Date          time.Time  `json:"date" gorm:"column:date" validate:"required"`
+	Amount        float64    `json:"amount" gorm:"column:amount" validate:"required,gt=0"`
+	Status        string     `json:"status" gorm:"column:status" validate:"required,validStatus"`
+	CustomerEmail string     `json:"customer_email,omitempty" gorm:"column:customer_email" validate:"omitempty,email,max=254"`
+	DueDate       *time.Time `json:"due_date,omitempty" gorm:"column:due_date" validate:"omitempty,gtefield=Date"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 }
 
 func (Invoice) TableName() string {
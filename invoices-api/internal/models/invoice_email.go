@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+const (
+	EmailKindInvoice  = "invoice"
+	EmailKindReminder = "reminder"
+
+	EmailSent   = "sent"
+	EmailFailed = "failed"
+)
+
+// InvoiceEmail is the send log entry of one email about an invoice.
+// ReminderLevel counts payment reminders from 1 and is 0 for the invoice
+// itself.
+type InvoiceEmail struct {
+	ID            uint      `json:"id" gorm:"primaryKey;column:id"`
+	TenantID      uint      `json:"tenant_id" gorm:"column:tenant_id;not null;index"`
+	InvoiceID     uint      `json:"invoice_id" gorm:"column:invoice_id;not null;index"`
+	Kind          string    `json:"kind" gorm:"column:kind;not null"`
+	ReminderLevel int       `json:"reminder_level" gorm:"column:reminder_level;not null;default:0"`
+	Recipient     string    `json:"recipient" gorm:"column:recipient;not null"`
+	Subject       string    `json:"subject" gorm:"column:subject;not null"`
+	Status        string    `json:"status" gorm:"column:status;not null"`
+	Error         string    `json:"error,omitempty" gorm:"column:error"`
+	MessageID     string    `json:"message_id,omitempty" gorm:"column:message_id"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (InvoiceEmail) TableName() string {
+	return "invoice_emails"
+}
+
+// SendInvoiceRequest overrides the invoice's customer email with To and adds
+// Message to the email body.
+type SendInvoiceRequest struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+// ReminderCandidate is an unpaid invoice together with the number of
+// reminders already sent for it.
+type ReminderCandidate struct {
+	Invoice
+	RemindersSent int `gorm:"column:reminders_sent"`
+}
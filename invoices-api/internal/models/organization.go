@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// DefaultOrganizationSlug identifies the organization that owns all data
+// created before multi-tenancy was introduced.
+const DefaultOrganizationSlug = "default"
+
+// Organization is a tenant. Every tenant-owned row carries its ID in a
+// tenant_id column.
+type Organization struct {
+	ID        uint      `json:"id" gorm:"primaryKey;column:id"`
+	Name      string    `json:"name" gorm:"column:name;not null"`
+	Slug      string    `json:"slug" gorm:"column:slug;not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	EventInvoiceCreated = "invoice.created"
+	EventInvoiceUpdated = "invoice.updated"
+	EventInvoicePaid    = "invoice.paid"
+	EventInvoiceDeleted = "invoice.deleted"
+
+	// EventAll subscribes to every event.
+	EventAll = "*"
+)
+
+// WebhookEvents lists the events a subscription can filter on.
+var WebhookEvents = []string{
+	EventInvoiceCreated,
+	EventInvoiceUpdated,
+	EventInvoicePaid,
+	EventInvoiceDeleted,
+}
+
+const AggregateInvoice = "invoice"
+
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	// OutboxDead events ran out of attempts and are no longer retried.
+	OutboxDead = "dead"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes. The relay publishes pending events in ID order, so ID
+// also orders the events of one aggregate.
+type OutboxEvent struct {
+	ID            uint            `json:"id" gorm:"primaryKey;column:id"`
+	EventID       string          `json:"event_id" gorm:"column:event_id;not null;uniqueIndex"`
+	TenantID      uint            `json:"tenant_id" gorm:"column:tenant_id;not null;index"`
+	AggregateType string          `json:"aggregate_type" gorm:"column:aggregate_type;not null;index:idx_outbox_events_aggregate,priority:1"`
+	AggregateID   uint            `json:"aggregate_id" gorm:"column:aggregate_id;not null;index:idx_outbox_events_aggregate,priority:2"`
+	Type          string          `json:"type" gorm:"column:type;not null"`
+	Payload       json.RawMessage `json:"payload" gorm:"column:payload;type:jsonb;serializer:json;not null"`
+	Status        string          `json:"status" gorm:"column:status;not null;default:pending;index"`
+	Attempts      int             `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	LastError     string          `json:"last_error,omitempty" gorm:"column:last_error"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty" gorm:"column:published_at"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// AggregateKey identifies the entity the event is about, e.g. "invoice:12".
+func (e *OutboxEvent) AggregateKey() string {
+	return e.AggregateType + ":" + strconv.FormatUint(uint64(e.AggregateID), 10)
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+type SummaryTotals struct {
+	Count   int64   `json:"count" example:"42"`
+	Amount  float64 `json:"amount" example:"63021.50"`
+	Average float64 `json:"average" example:"1500.51"`
+}
+
+type SummaryGroup struct {
+	Key     string  `json:"key" example:"Paid"`
+	Count   int64   `json:"count" example:"12"`
+	Amount  float64 `json:"amount" example:"18006.00"`
+	Average float64 `json:"average" example:"1500.50"`
+}
+
+type ReportSummary struct {
+	From      *time.Time     `json:"from,omitempty"`
+	To        *time.Time     `json:"to,omitempty"`
+	Period    string         `json:"period" example:"month"`
+	Totals    SummaryTotals  `json:"totals"`
+	ByStatus  []SummaryGroup `json:"by_status"`
+	ByService []SummaryGroup `json:"by_service"`
+	ByPeriod  []SummaryGroup `json:"by_period"`
+}
+
+// StatusTotals are the invoices with one status. Unpaid invoices are overdue
+// once their due date, or their date plus the payment terms, has passed.
+type StatusTotals struct {
+	Status        string
+	Count         int64
+	Amount        float64
+	OverdueCount  int64
+	OverdueAmount float64
+}
+
+type RevenuePoint struct {
+	Bucket         time.Time `json:"bucket"`
+	Amount         float64   `json:"amount" example:"4500.75"`
+	Count          int64     `json:"count" example:"3"`
+	RunningTotal   float64   `json:"running_total" example:"12000.00"`
+	MovingAverage  float64   `json:"moving_average" example:"3900.25"`
+	PreviousAmount *float64  `json:"previous_amount"`
+	ChangePct      *float64  `json:"change_pct"`
+}
+
+type RevenueSeries struct {
+	ServiceName string         `json:"service_name,omitempty" example:"DMP Service"`
+	Points      []RevenuePoint `json:"points"`
+}
+
+type PeriodComparison struct {
+	CurrentFrom    time.Time `json:"current_from"`
+	CurrentTo      time.Time `json:"current_to"`
+	PreviousFrom   time.Time `json:"previous_from"`
+	PreviousTo     time.Time `json:"previous_to"`
+	CurrentAmount  float64   `json:"current_amount" example:"12000.00"`
+	PreviousAmount float64   `json:"previous_amount" example:"10000.00"`
+	CurrentCount   int64     `json:"current_count" example:"8"`
+	PreviousCount  int64     `json:"previous_count" example:"7"`
+	Change         float64   `json:"change" example:"2000.00"`
+	ChangePct      *float64  `json:"change_pct" example:"20"`
+}
+
+type RevenueReport struct {
+	Interval   string           `json:"interval" example:"day"`
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Window     int              `json:"window" example:"7"`
+	Total      RevenueSeries    `json:"total"`
+	Series     []RevenueSeries  `json:"series"`
+	Comparison PeriodComparison `json:"comparison"`
+}
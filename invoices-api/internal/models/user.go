@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+type User struct {
+	ID           uint       `json:"id" gorm:"primaryKey;column:id"`
+	TenantID     uint       `json:"tenant_id" gorm:"column:tenant_id;not null;index"`
+	Email        string     `json:"email" gorm:"column:email;uniqueIndex;not null"`
+	Name         string     `json:"name" gorm:"column:name"`
+	PasswordHash string     `json:"-" gorm:"column:password_hash;not null"`
+	Role         string     `json:"role" gorm:"column:role;not null;default:viewer"`
+	Active       bool       `json:"active" gorm:"column:active;not null;default:true"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty" gorm:"column:last_login_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// RefreshToken stores only the SHA-256 of the opaque token handed to the
+// client. Tokens are single use: refreshing revokes the old one. TenantID is
+// the organization the session was started in.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey;column:id"`
+	TenantID  uint       `gorm:"column:tenant_id;not null;index"`
+	UserID    uint       `gorm:"column:user_id;not null;index"`
+	TokenHash string     `gorm:"column:token_hash;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookSubscription sends the listed events of its organization to URL.
+// Secret signs the payloads and is only shown when the subscription is
+// created.
+type WebhookSubscription struct {
+	ID          uint      `json:"id" gorm:"primaryKey;column:id"`
+	TenantID    uint      `json:"tenant_id" gorm:"column:tenant_id;not null;index"`
+	URL         string    `json:"url" gorm:"column:url;not null"`
+	Description string    `json:"description" gorm:"column:description"`
+	Secret      string    `json:"-" gorm:"column:secret;not null"`
+	Events      []string  `json:"events" gorm:"column:events;type:text;serializer:json;not null"`
+	Active      bool      `json:"active" gorm:"column:active;not null;default:true"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Matches reports whether the subscription wants event.
+func (s *WebhookSubscription) Matches(event string) bool {
+	for _, e := range s.Events {
+		if e == event || e == EventAll {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one subscription. Pending
+// deliveries are retried with backoff until they succeed or run out of
+// attempts.
+type WebhookDelivery struct {
+	ID             uint            `json:"id" gorm:"primaryKey;column:id"`
+	TenantID       uint            `json:"tenant_id" gorm:"column:tenant_id;not null;index"`
+	SubscriptionID uint            `json:"subscription_id" gorm:"column:subscription_id;not null;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	EventID        string          `json:"event_id" gorm:"column:event_id;not null;uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	Event          string          `json:"event" gorm:"column:event;not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"column:payload;type:jsonb;serializer:json;not null"`
+	Status         string          `json:"status" gorm:"column:status;not null;default:pending;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int             `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" gorm:"column:next_attempt_at;not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty" gorm:"column:last_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty" gorm:"column:response_status"`
+	ResponseBody   string          `json:"response_body,omitempty" gorm:"column:response_body"`
+	LastError      string          `json:"last_error,omitempty" gorm:"column:last_error"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	Subscription *WebhookSubscription `json:"-" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookEvent is the JSON body posted to webhook subscribers and sent on
+// the invoice event stream.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	TenantID  uint      `json:"tenant_id"`
+	Data      any       `json:"data"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+}
+
+// CreatedWebhook is returned once on creation; Secret is not retrievable
+// later.
+type CreatedWebhook struct {
+	WebhookSubscription
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return middleware.NewInternalError("Failed to create API key")
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.ListByUser")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var keys []models.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch API keys")
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.GetByHash")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("API key not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch API key")
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id, userID uint) error {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.Revoke")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		return middleware.NewInternalError("Failed to revoke API key")
+	}
+	if result.RowsAffected == 0 {
+		return middleware.NewNotFoundError("API key not found")
+	}
+
+	return nil
+}
+
+// TouchLastUsed records a use of the key. The condition keeps concurrent
+// requests from rewriting the row more often than once per interval.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, now time.Time, interval time.Duration) error {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.TouchLastUsed")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-interval)).
+		UpdateColumn("last_used_at", now).Error; err != nil {
+		return middleware.NewInternalError("Failed to update API key")
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// Reserve inserts the key, or takes over an expired row with the same key.
+// Both statements are atomic, so only one of several concurrent requests
+// with the same key gets to run.
+func (r *idempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*middleware.IdempotencyEntry, bool, error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyRepository.Reserve")
+	defer span.End()
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	db := r.db.WithContext(ctx)
+	now := time.Now()
+
+	record := models.IdempotencyKey{
+		TenantID:    tenant,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(ttl),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, false, middleware.NewInternalError("Failed to reserve idempotency key")
+	}
+	if result.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	result = db.Model(&models.IdempotencyKey{}).
+		Where("tenant_id = ? AND key = ? AND expires_at <= ?", tenant, key, now).
+		Updates(map[string]interface{}{
+			"fingerprint":  fingerprint,
+			"completed":    false,
+			"status_code":  0,
+			"content_type": "",
+			"body":         nil,
+			"expires_at":   now.Add(ttl),
+			"created_at":   now,
+		})
+	if result.Error != nil {
+		return nil, false, middleware.NewInternalError("Failed to reserve idempotency key")
+	}
+	if result.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("tenant_id = ? AND key = ?", tenant, key).First(&existing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// The other request released the key between our statements;
+			// report it as in flight and let the client retry.
+			return &middleware.IdempotencyEntry{Fingerprint: fingerprint}, false, nil
+		}
+		return nil, false, middleware.NewInternalError("Failed to fetch idempotency key")
+	}
+
+	return &middleware.IdempotencyEntry{
+		Fingerprint: existing.Fingerprint,
+		Completed:   existing.Completed,
+		Response: middleware.IdempotentResponse{
+			StatusCode:  existing.StatusCode,
+			ContentType: existing.ContentType,
+			Body:        existing.Body,
+		},
+	}, false, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, key string, response middleware.IdempotentResponse) error {
+	ctx, span := tracer.Start(ctx, "IdempotencyRepository.Complete")
+	defer span.End()
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("tenant_id = ? AND key = ?", tenant, key).
+		Updates(map[string]interface{}{
+			"completed":    true,
+			"status_code":  response.StatusCode,
+			"content_type": response.ContentType,
+			"body":         response.Body,
+		}).Error; err != nil {
+		return middleware.NewInternalError("Failed to store idempotent response")
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	ctx, span := tracer.Start(ctx, "IdempotencyRepository.Release")
+	defer span.End()
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND key = ? AND completed = ?", tenant, key, false).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return middleware.NewInternalError("Failed to release idempotency key")
+	}
+
+	return nil
+}
+
+// DeleteExpired removes expired keys of every tenant.
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyRepository.DeleteExpired")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, middleware.NewInternalError("Failed to delete expired idempotency keys")
+	}
+
+	return result.RowsAffected, nil
+}
@@ -3,15 +3,114 @@ package repository
 import (
 	"context"
 	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"sort"
+	"time"
 )
 
 type InvoiceRepository interface {
-	GetAll(ctx context.Context, params QueryParams) ([]models.Invoice, int64, error)
+	GetAll(ctx context.Context, params QueryParams) (*InvoiceList, error)
 	GetByID(ctx context.Context, id uint) (*models.Invoice, error)
 	Create(ctx context.Context, invoice *models.Invoice) error
 	Update(ctx context.Context, invoice *models.Invoice) error
 	Delete(ctx context.Context, id uint) error
-	Search(ctx context.Context, searchTerm string, params QueryParams) ([]models.Invoice, int64, error)
+	Search(ctx context.Context, searchTerm string, params QueryParams) (*InvoiceList, error)
+	Export(ctx context.Context, searchTerm string, params QueryParams, fn func(invoice *models.Invoice) error) error
+	CreateBatch(ctx context.Context, invoices []*models.Invoice) error
+	ExistingInvoiceNumbers(ctx context.Context, numbers []int) (map[int]bool, error)
+	Transaction(ctx context.Context, fn func(repo InvoiceRepository) error) error
+}
+
+type ReportRepository interface {
+	Summary(ctx context.Context, filter ReportFilter) (*models.ReportSummary, error)
+	Revenue(ctx context.Context, filter RevenueFilter) (*models.RevenueReport, error)
+	// StatusTotals counts the invoices of all organizations by status. It is
+	// not scoped to a tenant and serves the metrics only.
+	StatusTotals(ctx context.Context, now time.Time, terms time.Duration) ([]models.StatusTotals, error)
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	CountByRole(ctx context.Context, role string) (int64, error)
+	SetRole(ctx context.Context, id uint, role string) error
+	TouchLastLogin(ctx context.Context, id uint) error
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id uint) (bool, error)
+	RevokeAllRefreshTokens(ctx context.Context, userID uint) error
+}
+
+type OrganizationRepository interface {
+	List(ctx context.Context) ([]models.Organization, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Organization, error)
+	Create(ctx context.Context, org *models.Organization) error
+	TenantExists(ctx context.Context, id uint) (bool, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	Revoke(ctx context.Context, id, userID uint) error
+	TouchLastUsed(ctx context.Context, id uint, now time.Time, interval time.Duration) error
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, subscriptionID uint, status string, params QueryParams) ([]models.WebhookDelivery, int64, error)
+	RetryDelivery(ctx context.Context, subscriptionID, id uint) (*models.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	SaveDeliveryResult(ctx context.Context, delivery *models.WebhookDelivery) error
+	EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error
+}
+
+type OutboxRepository interface {
+	// WithRelayLock runs fn in a transaction holding the relay lock. It
+	// returns false without running fn when another relay holds the lock.
+	WithRelayLock(ctx context.Context, fn func(repo OutboxRepository) error) (bool, error)
+	Pending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint) error
+	MarkFailed(ctx context.Context, event *models.OutboxEvent) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+	// PublishedSince returns the events published after afterID, in the
+	// order they were published.
+	PublishedSince(ctx context.Context, afterID uint, limit int) ([]models.OutboxEvent, error)
+	LastPublished(ctx context.Context) (OutboxPosition, error)
+	PublishedAfter(ctx context.Context, after OutboxPosition, limit int) ([]models.OutboxEvent, error)
+}
+
+// OutboxPosition is a place in the order in which events were published.
+type OutboxPosition struct {
+	PublishedAt time.Time
+	ID          uint
+}
+
+type InvoiceEmailRepository interface {
+	Create(ctx context.Context, email *models.InvoiceEmail) error
+	ListByInvoice(ctx context.Context, invoiceID uint) ([]models.InvoiceEmail, error)
+	DueReminders(ctx context.Context, query ReminderQuery) ([]models.ReminderCandidate, error)
+	// WithDunningLock runs fn while holding the dunning lock. It returns false
+	// without running fn when another instance holds the lock.
+	WithDunningLock(ctx context.Context, fn func() error) (bool, error)
+}
+
+type IdempotencyRepository interface {
+	middleware.IdempotencyStore
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// InvoiceList is a page of a listing or search.
+type InvoiceList struct {
+	Invoices []models.Invoice `json:"invoices"`
+	Total    int64            `json:"total"`
+	// ChangedAt is when an invoice of the organization was last created,
+	// updated or deleted, read together with the total.
+	ChangedAt time.Time `json:"changed_at"`
 }
 
 type QueryParams struct {
@@ -21,7 +120,44 @@ type QueryParams struct {
 	SortDir string
 }
 
+// sortColumns are the columns listings and exports can be sorted by.
+var sortColumns = map[string]bool{
+	"id":             true,
+	"service_name":   true,
+	"invoice_number": true,
+	"date":           true,
+	"amount":         true,
+	"status":         true,
+	"customer_email": true,
+	"due_date":       true,
+	"created_at":     true,
+	"updated_at":     true,
+}
+
+func IsValidSortColumn(column string) bool {
+	return sortColumns[column]
+}
+
+// SortColumns lists the valid sort_by values.
+func SortColumns() []string {
+	columns := make([]string, 0, len(sortColumns))
+	for column := range sortColumns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// NewQueryParams drops a sortBy that is not a sort column and any sortDir
+// but asc and desc, so that neither can reach the ORDER BY clause.
 func NewQueryParams(page, limit int, sortBy, sortDir string) QueryParams {
+	if !IsValidSortColumn(sortBy) {
+		sortBy = ""
+	}
+	if sortDir != "desc" {
+		sortDir = "asc"
+	}
+
 	return QueryParams{
 		Page:    page,
 		Limit:   limit,
@@ -29,3 +165,33 @@ func NewQueryParams(page, limit int, sortBy, sortDir string) QueryParams {
 		SortDir: sortDir,
 	}
 }
+
+// ReportFilter narrows report queries. Zero values mean "unbounded"; To is
+// exclusive.
+type ReportFilter struct {
+	Search string
+	From   time.Time
+	To     time.Time
+	Period string
+}
+
+// RevenueFilter selects a revenue time series. From and To are required and
+// Window is the number of buckets averaged by the moving average.
+type RevenueFilter struct {
+	ReportFilter
+	Interval string
+	Status   string
+	Window   int
+}
+
+// ReminderQuery selects unpaid invoices that are owed a payment reminder.
+// Schedule[n] is how long after the due date reminder n+1 is sent, and
+// invoices without a due date are due Terms after their date. An invoice
+// whose last reminder failed is retried after RetryFailedAfter.
+type ReminderQuery struct {
+	Now              time.Time
+	Terms            time.Duration
+	Schedule         []time.Duration
+	RetryFailedAfter time.Duration
+	Limit            int
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"log/slog"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type invoiceEmailRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceEmailRepository(db *gorm.DB) InvoiceEmailRepository {
+	return &invoiceEmailRepository{
+		db: db,
+	}
+}
+
+func (r *invoiceEmailRepository) Create(ctx context.Context, email *models.InvoiceEmail) error {
+	ctx, span := tracer.Start(ctx, "InvoiceEmailRepository.Create")
+	defer span.End()
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	email.TenantID = tenant
+	if err := r.db.WithContext(ctx).Create(email).Error; err != nil {
+		return middleware.NewInternalError("Failed to record email")
+	}
+
+	return nil
+}
+
+func (r *invoiceEmailRepository) ListByInvoice(ctx context.Context, invoiceID uint) ([]models.InvoiceEmail, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceEmailRepository.ListByInvoice")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	emails := []models.InvoiceEmail{}
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).
+		Where("invoice_id = ?", invoiceID).
+		Order("id DESC").
+		Find(&emails).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch emails")
+	}
+
+	return emails, nil
+}
+
+// dunningLock is the advisory lock key that keeps reminders from being sent
+// by several instances at once.
+const dunningLock = 0x64756e6e696e67
+
+// DueReminders returns unpaid invoices of all tenants that are owed their
+// next reminder.
+func (r *invoiceEmailRepository) DueReminders(ctx context.Context, q ReminderQuery) ([]models.ReminderCandidate, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceEmailRepository.DueReminders")
+	defer span.End()
+
+	if len(q.Schedule) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	offsets := make([]interface{}, len(q.Schedule))
+	for i, offset := range q.Schedule {
+		offsets[i] = offset.Seconds()
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(q.Schedule)), ", ")
+
+	query := fmt.Sprintf(`
+		SELECT i.*, COALESCE(r.sent, 0) AS reminders_sent
+		FROM invoices i
+		LEFT JOIN (
+			SELECT invoice_id, COUNT(*) AS sent
+			FROM invoice_emails
+			WHERE kind = ? AND status = ?
+			GROUP BY invoice_id
+		) r ON r.invoice_id = i.id
+		WHERE i.status <> ?
+			AND COALESCE(i.customer_email, '') <> ''
+			AND COALESCE(r.sent, 0) < ?
+			AND COALESCE(i.due_date, i.date + make_interval(secs => ?))
+				+ make_interval(secs => (ARRAY[%s]::float8[])[COALESCE(r.sent, 0) + 1]) <= ?
+			AND NOT EXISTS (
+				SELECT 1 FROM invoice_emails f
+				WHERE f.invoice_id = i.id AND f.kind = ? AND f.status = ? AND f.created_at > ?
+			)
+		ORDER BY i.id
+		LIMIT ?`, placeholders)
+
+	args := []interface{}{models.EmailKindReminder, models.EmailSent, models.StatusPaid, len(q.Schedule), q.Terms.Seconds()}
+	args = append(args, offsets...)
+	args = append(args, q.Now, models.EmailKindReminder, models.EmailFailed, q.Now.Add(-q.RetryFailedAfter), q.Limit)
+
+	var candidates []models.ReminderCandidate
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&candidates).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch overdue invoices")
+	}
+
+	return candidates, nil
+}
+
+// WithDunningLock holds a session lock on a connection of its own rather
+// than a transaction lock, since a run sends mail for a while and an open
+// transaction would sit idle for as long.
+func (r *invoiceEmailRepository) WithDunningLock(ctx context.Context, fn func() error) (bool, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceEmailRepository.WithDunningLock")
+	defer span.End()
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return false, middleware.NewInternalError("Failed to acquire dunning lock")
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, middleware.NewInternalError("Failed to acquire dunning lock")
+	}
+	defer conn.Close()
+
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", dunningLock).Scan(&acquired); err != nil {
+		return false, middleware.NewInternalError("Failed to acquire dunning lock")
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		// Unlocking uses a fresh context so that it still happens after ctx
+		// was cancelled; the connection goes back to the pool.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", dunningLock); err != nil {
+			slog.Error("Failed to release dunning lock", "error", err)
+		}
+	}()
+
+	return true, fn()
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/database/dbtest"
+	"invoices-api/pkg/middleware"
+	"testing"
+	"time"
+)
+
+func TestDueReminders(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := middleware.WithTenant(context.Background(), dbtest.Organization(t, db, "acme").ID)
+	invoices := NewInvoiceRepository(db, nil, 0, false)
+	repo := NewInvoiceEmailRepository(db)
+
+	const day = 24 * time.Hour
+	now := time.Now()
+
+	number := 0
+	create := func(status, email string, date time.Time, due *time.Time) *models.Invoice {
+		t.Helper()
+		number++
+		invoice := &models.Invoice{
+			ServiceName:   "Consulting",
+			InvoiceNumber: number,
+			Date:          date,
+			DueDate:       due,
+			Amount:        100,
+			Status:        status,
+			CustomerEmail: email,
+		}
+		if err := invoices.Create(ctx, invoice); err != nil {
+			t.Fatal(err)
+		}
+		return invoice
+	}
+	record := func(invoice *models.Invoice, status string) {
+		t.Helper()
+		if err := repo.Create(ctx, &models.InvoiceEmail{
+			InvoiceID: invoice.ID,
+			Kind:      models.EmailKindReminder,
+			Recipient: invoice.CustomerEmail,
+			Subject:   "Reminder",
+			Status:    status,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	// Without a due date, invoices are due 30 days after their date.
+	first := create("Pending", "a@example.com", now.Add(-40*day), nil)
+	create("Pending", "b@example.com", now.Add(-35*day), nil)
+	create(models.StatusPaid, "c@example.com", now.Add(-40*day), nil)
+	create("Pending", "", now.Add(-40*day), nil)
+
+	notYet := create("Pending", "d@example.com", now.Add(-20*day), ago(10*day))
+	record(notYet, models.EmailSent)
+
+	second := create("Pending", "e@example.com", now.Add(-30*day), ago(20*day))
+	record(second, models.EmailSent)
+
+	exhausted := create("Pending", "f@example.com", now.Add(-90*day), ago(60*day))
+	record(exhausted, models.EmailSent)
+	record(exhausted, models.EmailSent)
+
+	failed := create("Pending", "g@example.com", now.Add(-40*day), nil)
+	record(failed, models.EmailFailed)
+
+	q := ReminderQuery{
+		Now:              now,
+		Terms:            30 * day,
+		Schedule:         []time.Duration{7 * day, 14 * day},
+		RetryFailedAfter: day,
+		Limit:            10,
+	}
+
+	check := func(want map[uint]int) {
+		t.Helper()
+		candidates, err := repo.DueReminders(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[uint]int)
+		for _, candidate := range candidates {
+			got[candidate.ID] = candidate.RemindersSent
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for id, sent := range want {
+			if s, ok := got[id]; !ok || s != sent {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	}
+
+	// The failed reminder of the last invoice was too recent to retry; the
+	// second reminder of the invoice sent before is due.
+	check(map[uint]int{first.ID: 0, second.ID: 1})
+
+	// A failed attempt is retried once RetryFailedAfter has passed, and does
+	// not count as a reminder sent.
+	q.Now = time.Now()
+	q.RetryFailedAfter = 0
+	check(map[uint]int{first.ID: 0, second.ID: 1, failed.ID: 0})
+
+	q.Limit = 1
+	check(map[uint]int{first.ID: 0})
+}
+
+func TestWithDunningLock(t *testing.T) {
+	repo := NewInvoiceEmailRepository(dbtest.Open(t))
+	ctx := context.Background()
+
+	ran := false
+	acquired, err := repo.WithDunningLock(ctx, func() error {
+		ran = true
+		// Another instance does not get the lock in the meantime.
+		held, err := repo.WithDunningLock(ctx, func() error {
+			t.Error("ran while the lock was held")
+			return nil
+		})
+		if err != nil || held {
+			t.Errorf("got %t, %v while the lock was held", held, err)
+		}
+		return nil
+	})
+	if err != nil || !acquired || !ran {
+		t.Fatalf("got %t, %v, want the lock", acquired, err)
+	}
+
+	// The lock is released afterwards.
+	acquired, err = repo.WithDunningLock(ctx, func() error { return nil })
+	if err != nil || !acquired {
+		t.Fatalf("got %t, %v after release, want the lock", acquired, err)
+	}
+}
@@ -3,92 +3,272 @@ package repository
 import (
 	"context"
 	"fmt"
+	"invoices-api/internal/cache"
 	"invoices-api/internal/models"
+	"invoices-api/pkg/database"
 	"invoices-api/pkg/middleware"
-	"sync"
+	"log/slog"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// invoiceColumns is selected by list queries.
+const invoiceColumns = "id, tenant_id, service_name, invoice_number, date, amount, status, customer_email, due_date, created_at, updated_at"
+
 const (
 	defaultTimeout = 10 * time.Second
+	exportTimeout  = 5 * time.Minute
 	maxSearchLen   = 100
+	lookupChunk    = 1000
+	insertChunk    = 500
 )
 
 type invoiceRepository struct {
-	db    *gorm.DB
-	cache sync.Map
+	db *gorm.DB
+	// replica serves listings and search, which tolerate replication lag.
+	replica *gorm.DB
+
+	items    cache.Cache
+	lists    cache.Cache
+	cacheTTL time.Duration
+	// cacheLists is off while listings are read from replicas. A replica
+	// that has not caught up with a write yet would return the rows the
+	// write's invalidation just removed, and they would stay cached for the
+	// whole TTL.
+	cacheLists bool
+	// pending collects the cache entries made stale inside Transaction. They
+	// are invalidated once it commits, so that no reader can cache the old
+	// rows again in between.
+	pending *invalidation
+	// inTransaction prevents uncommitted rows from being cached.
+	inTransaction bool
+}
+
+type invalidation struct {
+	keys []string
+	tags []string
 }
 
-func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+// NewInvoiceRepository caches single invoices and listings in c for ttl.
+// Every write through the repository invalidates the entries it affects.
+// With replicas, listings are read from them and not cached.
+func NewInvoiceRepository(db *gorm.DB, c cache.Cache, ttl time.Duration, replicas bool) InvoiceRepository {
+	if c == nil {
+		c = cache.Nop{}
+	}
+
 	return &invoiceRepository{
-		db: db,
+		db:         db,
+		replica:    database.OnReplica(db),
+		items:      cache.Instrument(c, "invoice"),
+		lists:      cache.Instrument(c, "invoice_list"),
+		cacheTTL:   ttl,
+		cacheLists: !replicas,
 	}
 }
 
+// orderBy sorts by the column validated in NewQueryParams.
+func orderBy(query *gorm.DB, params QueryParams) *gorm.DB {
+	if params.SortBy == "" {
+		return query
+	}
+	return query.Order(clause.OrderByColumn{
+		Column: clause.Column{Name: params.SortBy},
+		Desc:   params.SortDir == "desc",
+	})
+}
+
 func (r *invoiceRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, defaultTimeout)
 }
 
-func (r *invoiceRepository) GetAll(ctx context.Context, params QueryParams) ([]models.Invoice, int64, error) {
+// Transaction runs fn against a repository bound to a single database
+// transaction. Calls made through it nest as savepoints, and everything is
+// rolled back if fn returns an error.
+func (r *invoiceRepository) Transaction(ctx context.Context, fn func(repo InvoiceRepository) error) error {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.Transaction")
+	defer span.End()
+
+	pending := r.pending
+	if pending == nil {
+		pending = &invalidation{}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&invoiceRepository{
+			db:            tx,
+			replica:       tx,
+			items:         r.items,
+			lists:         r.lists,
+			cacheTTL:      r.cacheTTL,
+			cacheLists:    r.cacheLists,
+			pending:       pending,
+			inTransaction: true,
+		})
+	})
+
+	// Nested transactions leave the invalidation to the outermost one. A
+	// rolled back transaction may have invalidated entries before failing.
+	if !r.inTransaction {
+		r.flushInvalidation(context.WithoutCancel(ctx), pending)
+	}
+	return err
+}
+
+// invalidate drops the cached listings of the tenant and the given invoices,
+// or defers it to the end of the transaction.
+func (r *invoiceRepository) invalidate(ctx context.Context, tenant uint, ids ...uint) {
+	inv := &invalidation{tags: []string{invoiceListTag(tenant)}}
+	for _, id := range ids {
+		inv.keys = append(inv.keys, invoiceCacheKey(tenant, id))
+	}
+
+	if r.pending != nil {
+		r.pending.keys = append(r.pending.keys, inv.keys...)
+		r.pending.tags = append(r.pending.tags, inv.tags...)
+		return
+	}
+	r.flushInvalidation(context.WithoutCancel(ctx), inv)
+}
+
+func (r *invoiceRepository) flushInvalidation(ctx context.Context, inv *invalidation) {
+	if len(inv.keys) > 0 {
+		if err := r.items.Delete(ctx, inv.keys...); err != nil {
+			slog.ErrorContext(ctx, "Failed to invalidate cached invoices", "error", err)
+		}
+	}
+	if len(inv.tags) > 0 {
+		if err := r.lists.InvalidateTags(ctx, inv.tags...); err != nil {
+			slog.ErrorContext(ctx, "Failed to invalidate cached invoice listings", "error", err)
+		}
+	}
+}
+
+// cachedList answers a listing from the cache or loads and caches it. Inside
+// a transaction, or when listings are read from replicas, the cache is
+// bypassed.
+func (r *invoiceRepository) cachedList(ctx context.Context, searchTerm string, params QueryParams, load func() (*InvoiceList, error)) (*InvoiceList, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.inTransaction || !r.cacheLists || r.cacheTTL <= 0 {
+		return load()
+	}
+
+	key := invoiceListCacheKey(tenant, searchTerm, params)
+	var cached InvoiceList
+	hit := cache.GetJSON(ctx, r.lists, key, &cached)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", hit))
+	if hit {
+		return &cached, nil
+	}
+
+	list, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	cache.SetJSON(ctx, r.lists, key, list, r.cacheTTL, invoiceListTag(tenant))
+	return list, nil
+}
+
+func (r *invoiceRepository) GetAll(ctx context.Context, params QueryParams) (*InvoiceList, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.GetAll")
+	defer span.End()
+
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	var invoices []models.Invoice
-	var total int64
+	return r.cachedList(ctx, "", params, func() (*InvoiceList, error) {
+		return r.getAll(ctx, params)
+	})
+}
 
-	queryCount := r.db.WithContext(ctx).Model(&models.Invoice{})
-	queryFetch := r.db.WithContext(ctx).Model(&models.Invoice{})
+func (r *invoiceRepository) getAll(ctx context.Context, params QueryParams) (*InvoiceList, error) {
+	var list InvoiceList
 
-	if err := queryCount.Count(&total).Error; err != nil {
-		return nil, 0, middleware.NewInternalError("Failed to count invoices")
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
 	}
 
-	if params.SortBy != "" {
-		order := fmt.Sprintf("%s %s", params.SortBy, params.SortDir)
-		queryFetch = queryFetch.Order(order)
+	queryCount := r.replica.WithContext(ctx).Model(&models.Invoice{}).Scopes(tenantScope(ctx))
+	queryFetch := r.replica.WithContext(ctx).Model(&models.Invoice{}).Scopes(tenantScope(ctx))
+
+	var err error
+	if list.Total, list.ChangedAt, err = countInvoices(ctx, queryCount); err != nil {
+		return nil, middleware.NewInternalError("Failed to count invoices")
 	}
 
+	queryFetch = orderBy(queryFetch, params)
+
 	offset := (params.Page - 1) * params.Limit
 	queryFetch = queryFetch.Offset(offset).Limit(params.Limit)
 
-	if err := queryFetch.Select("id, service_name, invoice_number, date, amount, status, created_at, updated_at").
-		Find(&invoices).Error; err != nil {
-		return nil, 0, middleware.NewInternalError("Failed to fetch invoices")
+	if err := queryFetch.Select(invoiceColumns).
+		Find(&list.Invoices).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch invoices")
 	}
 
-	return invoices, total, nil
+	return &list, nil
 }
 
 func (r *invoiceRepository) GetByID(ctx context.Context, id uint) (*models.Invoice, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.GetByID")
+	defer span.End()
+
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	if cached, ok := r.cache.Load(id); ok {
-		if invoice, ok := cached.(*models.Invoice); ok {
-			return invoice, nil
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := invoiceCacheKey(tenant, id)
+	useCache := !r.inTransaction && r.cacheTTL > 0
+
+	var cached models.Invoice
+	if useCache {
+		hit := cache.GetJSON(ctx, r.items, key, &cached)
+		span.SetAttributes(attribute.Bool("cache.hit", hit))
+		if hit {
+			return &cached, nil
 		}
 	}
 
 	var invoice models.Invoice
-	if err := r.db.WithContext(ctx).First(&invoice, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).First(&invoice, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, middleware.NewNotFoundError("Invoice not found")
 		}
 		return nil, middleware.NewInternalError("Failed to fetch invoice")
 	}
 
-	r.cache.Store(id, &invoice)
+	if useCache {
+		cache.SetJSON(ctx, r.items, key, &invoice, r.cacheTTL)
+	}
 
 	return &invoice, nil
 }
 
 func (r *invoiceRepository) Create(ctx context.Context, invoice *models.Invoice) error {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.Create")
+	defer span.End()
+
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	invoice.TenantID = tenant
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := r.checkDuplicateInvoiceNumber(ctx, tx, invoice.InvoiceNumber); err != nil {
 			return err
 		}
@@ -97,17 +277,93 @@ func (r *invoiceRepository) Create(ctx context.Context, invoice *models.Invoice)
 			return middleware.NewInternalError("Failed to create invoice")
 		}
 
+		if err := touchInvoices(tx, tenant); err != nil {
+			return err
+		}
+		return writeOutbox(tx, tenant, []invoiceEvent{{event: models.EventInvoiceCreated, invoice: *invoice}})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, tenant)
+	return nil
+}
+
+// CreateBatch inserts all invoices in a single transaction. Duplicate invoice
+// numbers are checked again inside the transaction so that a concurrent
+// insert fails the batch instead of the unique constraint.
+func (r *invoiceRepository) CreateBatch(ctx context.Context, invoices []*models.Invoice) error {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.CreateBatch")
+	defer span.End()
+
+	if len(invoices) == 0 {
 		return nil
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	numbers := make([]int, len(invoices))
+	for i, invoice := range invoices {
+		invoice.TenantID = tenant
+		numbers[i] = invoice.InvoiceNumber
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := r.existingInvoiceNumbers(ctx, tx, numbers)
+		if err != nil {
+			return err
+		}
+
+		for _, number := range numbers {
+			if existing[number] {
+				return middleware.NewBadRequestError(fmt.Sprintf("Invoice number %d already exists", number))
+			}
+		}
+
+		if err := tx.CreateInBatches(invoices, insertChunk).Error; err != nil {
+			return middleware.NewInternalError("Failed to create invoices")
+		}
+
+		if err := touchInvoices(tx, tenant); err != nil {
+			return err
+		}
+
+		events := make([]invoiceEvent, len(invoices))
+		for i, invoice := range invoices {
+			events[i] = invoiceEvent{event: models.EventInvoiceCreated, invoice: *invoice}
+		}
+		return writeOutbox(tx, tenant, events)
 	})
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, tenant)
+	return nil
 }
 
 func (r *invoiceRepository) Update(ctx context.Context, invoice *models.Invoice) error {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.Update")
+	defer span.End()
+
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var existing models.Invoice
-		if err := tx.First(&existing, invoice.ID).Error; err != nil {
+		if err := tx.Scopes(tenantScope(ctx)).First(&existing, invoice.ID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return middleware.NewNotFoundError("Invoice not found")
 			}
@@ -120,73 +376,200 @@ func (r *invoiceRepository) Update(ctx context.Context, invoice *models.Invoice)
 			}
 		}
 
+		// The row was found in this tenant above, and the tenant of an
+		// invoice never changes.
+		invoice.TenantID = tenant
 		if err := tx.Save(invoice).Error; err != nil {
 			return middleware.NewInternalError("Failed to update invoice")
 		}
 
-		r.cache.Delete(invoice.ID)
+		if err := touchInvoices(tx, tenant); err != nil {
+			return err
+		}
 
-		return nil
+		events := []invoiceEvent{{event: models.EventInvoiceUpdated, invoice: *invoice}}
+		if invoice.Status == models.StatusPaid && existing.Status != models.StatusPaid {
+			events = append(events, invoiceEvent{event: models.EventInvoicePaid, invoice: *invoice})
+		}
+		return writeOutbox(tx, tenant, events)
 	})
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, tenant, invoice.ID)
+	return nil
 }
 
 func (r *invoiceRepository) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.Delete")
+	defer span.End()
+
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	result := r.db.WithContext(ctx).Delete(&models.Invoice{}, id)
-	if result.Error != nil {
-		return middleware.NewInternalError("Failed to delete invoice")
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
 	}
 
-	if result.RowsAffected == 0 {
-		return middleware.NewNotFoundError("Invoice not found")
-	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The deleted row is returned so that it can be sent with the event.
+		var deleted models.Invoice
+		result := tx.Clauses(clause.Returning{}).Scopes(tenantScope(ctx)).Delete(&deleted, id)
+		if result.Error != nil {
+			return middleware.NewInternalError("Failed to delete invoice")
+		}
+
+		if result.RowsAffected == 0 {
+			return middleware.NewNotFoundError("Invoice not found")
+		}
 
-	r.cache.Delete(id)
+		if err := touchInvoices(tx, tenant); err != nil {
+			return err
+		}
+		return writeOutbox(tx, tenant, []invoiceEvent{{event: models.EventInvoiceDeleted, invoice: deleted}})
+	})
+	if err != nil {
+		return err
+	}
 
+	r.invalidate(ctx, tenant, id)
 	return nil
 }
 
-func (r *invoiceRepository) Search(ctx context.Context, searchTerm string, params QueryParams) ([]models.Invoice, int64, error) {
+func (r *invoiceRepository) Search(ctx context.Context, searchTerm string, params QueryParams) (*InvoiceList, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.Search")
+	defer span.End()
+
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	var invoices []models.Invoice
-	var total int64
-
 	if len(searchTerm) > maxSearchLen {
 		searchTerm = searchTerm[:maxSearchLen]
 	}
 
-	query := r.db.WithContext(ctx).Model(&models.Invoice{})
+	return r.cachedList(ctx, searchTerm, params, func() (*InvoiceList, error) {
+		return r.search(ctx, searchTerm, params)
+	})
+}
+
+func (r *invoiceRepository) search(ctx context.Context, searchTerm string, params QueryParams) (*InvoiceList, error) {
+	var list InvoiceList
+
+	query := r.replica.WithContext(ctx).Model(&models.Invoice{}).Scopes(tenantScope(ctx))
 
 	if searchTerm != "" {
 		query = query.Where("service_name ILIKE ?", fmt.Sprintf("%%%s%%", searchTerm))
 	}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, middleware.NewInternalError("Failed to count search results")
+	var err error
+	if list.Total, list.ChangedAt, err = countInvoices(ctx, query.Session(&gorm.Session{})); err != nil {
+		return nil, middleware.NewInternalError("Failed to count search results")
 	}
 
-	if params.SortBy != "" {
-		order := fmt.Sprintf("%s %s", params.SortBy, params.SortDir)
-		query = query.Order(order)
-	}
+	query = orderBy(query, params)
 
 	offset := (params.Page - 1) * params.Limit
 	if err := query.Offset(offset).
 		Limit(params.Limit).
-		Select("id, service_name, invoice_number, date, amount, status, created_at, updated_at").
-		Find(&invoices).Error; err != nil {
-		return nil, 0, middleware.NewInternalError("Failed to fetch search results")
+		Select(invoiceColumns).
+		Find(&list.Invoices).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch search results")
+	}
+
+	return &list, nil
+}
+
+// Export walks every invoice matching the search term through a database
+// cursor and hands them to fn one at a time. Pagination is ignored.
+func (r *invoiceRepository) Export(ctx context.Context, searchTerm string, params QueryParams, fn func(invoice *models.Invoice) error) error {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.Export")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	if len(searchTerm) > maxSearchLen {
+		searchTerm = searchTerm[:maxSearchLen]
+	}
+
+	if _, err := tenantID(ctx); err != nil {
+		return err
+	}
+
+	query := r.replica.WithContext(ctx).Model(&models.Invoice{}).Scopes(tenantScope(ctx))
+
+	if searchTerm != "" {
+		query = query.Where("service_name ILIKE ?", fmt.Sprintf("%%%s%%", searchTerm))
+	}
+
+	query = orderBy(query, params)
+
+	rows, err := query.Order("id").
+		Select(invoiceColumns).
+		Rows()
+	if err != nil {
+		return middleware.NewInternalError("Failed to export invoices")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var invoice models.Invoice
+		if err := r.db.ScanRows(rows, &invoice); err != nil {
+			return middleware.NewInternalError("Failed to read exported invoice")
+		}
+
+		if err := fn(&invoice); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return middleware.NewInternalError("Failed to export invoices")
+	}
+
+	return nil
+}
+
+func (r *invoiceRepository) ExistingInvoiceNumbers(ctx context.Context, numbers []int) (map[int]bool, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceRepository.ExistingInvoiceNumbers")
+	defer span.End()
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.existingInvoiceNumbers(ctx, r.db, numbers)
+}
+
+func (r *invoiceRepository) existingInvoiceNumbers(ctx context.Context, tx *gorm.DB, numbers []int) (map[int]bool, error) {
+	existing := make(map[int]bool)
+
+	for start := 0; start < len(numbers); start += lookupChunk {
+		end := min(start+lookupChunk, len(numbers))
+
+		var found []int
+		if err := tx.WithContext(ctx).Model(&models.Invoice{}).
+			Scopes(tenantScope(ctx)).
+			Where("invoice_number IN ?", numbers[start:end]).
+			Pluck("invoice_number", &found).Error; err != nil {
+			return nil, middleware.NewInternalError("Failed to check existing invoice numbers")
+		}
+
+		for _, number := range found {
+			existing[number] = true
+		}
 	}
 
-	return invoices, total, nil
+	return existing, nil
 }
 
 func (r *invoiceRepository) checkDuplicateInvoiceNumber(ctx context.Context, tx *gorm.DB, invoiceNumber int, excludeID ...uint) error {
-	query := tx.WithContext(ctx).Model(&models.Invoice{}).Where("invoice_number = ?", invoiceNumber)
+	query := tx.WithContext(ctx).Model(&models.Invoice{}).Scopes(tenantScope(ctx)).Where("invoice_number = ?", invoiceNumber)
 
 	if len(excludeID) > 0 {
 		query = query.Where("id != ?", excludeID[0])
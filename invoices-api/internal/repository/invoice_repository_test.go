@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+)
+
+func TestInvoiceListChangedAt(t *testing.T) {
+	ts := setupTenants(t)
+	repo := NewInvoiceRepository(ts.db, nil, 0, false)
+	params := NewQueryParams(1, 10, "", "")
+
+	before, err := repo.GetAll(ts.acme, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := repo.GetAll(ts.globex, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting the only invoice leaves no newer invoice behind, but the
+	// listing has still changed.
+	if err := repo.Delete(ts.acme, ts.acmeInvoice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := repo.Search(ts.acme, "", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ChangedAt.After(before.ChangedAt) {
+		t.Errorf("ChangedAt = %s after a delete, want later than %s", after.ChangedAt, before.ChangedAt)
+	}
+
+	unchanged, err := repo.GetAll(ts.globex, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unchanged.ChangedAt.Equal(other.ChangedAt) {
+		t.Errorf("ChangedAt of another organization moved from %s to %s", other.ChangedAt, unchanged.ChangedAt)
+	}
+}
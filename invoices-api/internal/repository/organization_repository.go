@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+
+	"gorm.io/gorm"
+)
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+func (r *organizationRepository) List(ctx context.Context) ([]models.Organization, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationRepository.List")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var orgs []models.Organization
+	if err := r.db.WithContext(ctx).Order("id").Find(&orgs).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch organizations")
+	}
+
+	return orgs, nil
+}
+
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationRepository.GetBySlug")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var org models.Organization
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&org).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("Organization not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch organization")
+	}
+
+	return &org, nil
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	ctx, span := tracer.Start(ctx, "OrganizationRepository.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Organization{}).Where("slug = ?", org.Slug).Count(&count).Error; err != nil {
+			return middleware.NewInternalError("Failed to check duplicate organization")
+		}
+		if count > 0 {
+			return middleware.NewBadRequestError("An organization with this slug already exists")
+		}
+
+		if err := tx.Create(org).Error; err != nil {
+			return middleware.NewInternalError("Failed to create organization")
+		}
+
+		return nil
+	})
+}
+
+// TenantExists implements middleware.TenantLookup.
+func (r *organizationRepository) TenantExists(ctx context.Context, id uint) (bool, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationRepository.TenantExists")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Organization{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, middleware.NewInternalError("Failed to fetch organization")
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxRelayLock is the advisory lock key that keeps a single relay
+// publishing at a time, across all instances.
+const outboxRelayLock = 0x6f7574626f78
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (r *outboxRepository) WithRelayLock(ctx context.Context, fn func(repo OutboxRepository) error) (bool, error) {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.WithRelayLock")
+	defer span.End()
+
+	acquired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxRelayLock).Scan(&acquired).Error; err != nil {
+			return middleware.NewInternalError("Failed to acquire outbox lock")
+		}
+		if !acquired {
+			return nil
+		}
+		return fn(&outboxRepository{db: tx})
+	})
+	return acquired, err
+}
+
+// Pending returns due events in ID order. An event is left out while an
+// earlier event of the same aggregate is waiting for a retry, so that the
+// events of one invoice are never published out of order.
+func (r *outboxRepository) Pending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.Pending")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var events []models.OutboxEvent
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events earlier
+			WHERE earlier.aggregate_type = outbox_events.aggregate_type
+				AND earlier.aggregate_id = outbox_events.aggregate_id
+				AND earlier.id < outbox_events.id
+				AND earlier.status = ?
+				AND earlier.next_attempt_at > ?
+		)`, models.OutboxPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch outbox events")
+	}
+
+	return events, nil
+}
+
+// MarkPublished takes the publishing time from the database clock at the
+// time of the update, which the relay lock orders across instances, so that
+// every instance sees the same publish order.
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []uint) error {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.MarkPublished")
+	defer span.End()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":       models.OutboxPublished,
+			"published_at": gorm.Expr("clock_timestamp()"),
+			"last_error":   "",
+		}).Error; err != nil {
+		return middleware.NewInternalError("Failed to update outbox events")
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, event *models.OutboxEvent) error {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.MarkFailed")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", event.ID).
+		Updates(map[string]interface{}{
+			"status":          event.Status,
+			"attempts":        event.Attempts,
+			"next_attempt_at": event.NextAttemptAt,
+			"last_error":      event.LastError,
+		}).Error; err != nil {
+		return middleware.NewInternalError("Failed to update outbox event")
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.DeletePublished")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND published_at < ?", models.OutboxPublished, before).
+		Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, middleware.NewInternalError("Failed to delete outbox events")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// PublishedSince returns the tenant's events published after the event
+// afterID, in the order they were published. That is not ID order: an event
+// that was retried is published after events written later. It returns a
+// not found error when afterID is unknown, e.g. because it has been cleaned
+// up, so that the caller can tell the client to reload.
+func (r *outboxRepository) PublishedSince(ctx context.Context, afterID uint, limit int) ([]models.OutboxEvent, error) {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.PublishedSince")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var after models.OutboxEvent
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).
+		Where("id = ? AND status = ?", afterID, models.OutboxPublished).
+		Take(&after).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("Event not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch events")
+	}
+
+	var events []models.OutboxEvent
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(ctx), publishedAfter(OutboxPosition{PublishedAt: *after.PublishedAt, ID: after.ID})).
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch events")
+	}
+
+	return events, nil
+}
+
+// LastPublished returns the position of the newest published event of any
+// tenant, or the zero position when there is none.
+func (r *outboxRepository) LastPublished(ctx context.Context) (OutboxPosition, error) {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.LastPublished")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var events []models.OutboxEvent
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", models.OutboxPublished).
+		Order("published_at DESC, id DESC").
+		Limit(1).
+		Find(&events).Error; err != nil {
+		return OutboxPosition{}, middleware.NewInternalError("Failed to fetch events")
+	}
+
+	if len(events) == 0 || events[0].PublishedAt == nil {
+		return OutboxPosition{}, nil
+	}
+	return OutboxPosition{PublishedAt: *events[0].PublishedAt, ID: events[0].ID}, nil
+}
+
+// PublishedAfter returns the events of all tenants published after the
+// given position, in the order they were published.
+func (r *outboxRepository) PublishedAfter(ctx context.Context, after OutboxPosition, limit int) ([]models.OutboxEvent, error) {
+	ctx, span := tracer.Start(ctx, "OutboxRepository.PublishedAfter")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var events []models.OutboxEvent
+	if err := r.db.WithContext(ctx).
+		Scopes(publishedAfter(after)).
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch events")
+	}
+
+	return events, nil
+}
+
+// publishedAfter selects published events after a position in publish
+// order. The relay marks a batch published at once, in ID order, so events
+// with the same publishing time are ordered by ID.
+func publishedAfter(after OutboxPosition) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("status = ? AND (published_at, id) > (?, ?)", models.OutboxPublished, after.PublishedAt, after.ID).
+			Order("published_at, id")
+	}
+}
+
+// invoiceEvent is an event about a single invoice, written by the invoice
+// repository.
+type invoiceEvent struct {
+	event   string
+	invoice models.Invoice
+}
+
+// writeOutbox records events on the caller's transaction, so that they are
+// stored if and only if the change they describe is committed.
+func writeOutbox(tx *gorm.DB, tenant uint, events []invoiceEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]models.OutboxEvent, len(events))
+	for i, e := range events {
+		payload, err := json.Marshal(e.invoice)
+		if err != nil {
+			return middleware.NewInternalError("Failed to encode event")
+		}
+
+		rows[i] = models.OutboxEvent{
+			EventID:       newEventID(),
+			TenantID:      tenant,
+			AggregateType: models.AggregateInvoice,
+			AggregateID:   e.invoice.ID,
+			Type:          e.event,
+			Payload:       payload,
+			Status:        models.OutboxPending,
+			NextAttemptAt: now,
+		}
+	}
+
+	if err := tx.CreateInBatches(rows, insertChunk).Error; err != nil {
+		return middleware.NewInternalError("Failed to record events")
+	}
+
+	return nil
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/database"
+	"invoices-api/pkg/middleware"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+	PeriodYear    = "year"
+)
+
+// periodKeys maps a reporting period to the SQL expression used to bucket
+// invoice dates. Values are inlined into queries, so only these are allowed.
+var periodKeys = map[string]string{
+	PeriodMonth:   `to_char(date_trunc('month', date), 'YYYY-MM')`,
+	PeriodQuarter: `to_char(date_trunc('quarter', date), 'YYYY-"Q"Q')`,
+	PeriodYear:    `to_char(date_trunc('year', date), 'YYYY')`,
+}
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+var revenueIntervals = map[string]bool{
+	IntervalDay:   true,
+	IntervalWeek:  true,
+	IntervalMonth: true,
+}
+
+func IsValidPeriod(period string) bool {
+	_, ok := periodKeys[period]
+	return ok
+}
+
+func IsValidInterval(interval string) bool {
+	return revenueIntervals[interval]
+}
+
+// reportRepository only reads, so all its queries go to a read replica when
+// one is configured.
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{
+		db: database.OnReplica(db),
+	}
+}
+
+type summaryRow struct {
+	Status          *string
+	ServiceName     *string
+	Period          *string
+	GroupingStatus  int
+	GroupingService int
+	GroupingPeriod  int
+	Count           int64
+	Amount          float64
+	Average         float64
+}
+
+// Summary computes the overall totals and the per status, per service and
+// per period breakdowns in a single GROUPING SETS query.
+func (r *reportRepository) Summary(ctx context.Context, filter ReportFilter) (*models.ReportSummary, error) {
+	ctx, span := tracer.Start(ctx, "ReportRepository.Summary")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	periodKey, ok := periodKeys[filter.Period]
+	if !ok {
+		return nil, middleware.NewBadRequestError("Period must be one of: month, quarter, year")
+	}
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	selectSQL := fmt.Sprintf(`status, service_name, %[1]s AS period,
+		GROUPING(status) AS grouping_status,
+		GROUPING(service_name) AS grouping_service,
+		GROUPING(%[1]s) AS grouping_period,
+		COUNT(*) AS count,
+		COALESCE(SUM(amount), 0) AS amount,
+		COALESCE(AVG(amount), 0) AS average`, periodKey)
+	groupSQL := fmt.Sprintf("GROUPING SETS ((), (status), (service_name), (%s))", periodKey)
+
+	var rows []summaryRow
+	if err := r.filtered(ctx, filter).
+		Select(selectSQL).
+		Group(groupSQL).
+		Scan(&rows).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to build invoice summary")
+	}
+
+	summary := &models.ReportSummary{
+		Period:    filter.Period,
+		ByStatus:  []models.SummaryGroup{},
+		ByService: []models.SummaryGroup{},
+		ByPeriod:  []models.SummaryGroup{},
+	}
+	if !filter.From.IsZero() {
+		summary.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		summary.To = &filter.To
+	}
+
+	for _, row := range rows {
+		switch {
+		case row.GroupingStatus == 0:
+			summary.ByStatus = append(summary.ByStatus, row.group(row.Status))
+		case row.GroupingService == 0:
+			summary.ByService = append(summary.ByService, row.group(row.ServiceName))
+		case row.GroupingPeriod == 0:
+			summary.ByPeriod = append(summary.ByPeriod, row.group(row.Period))
+		default:
+			summary.Totals = models.SummaryTotals{
+				Count:   row.Count,
+				Amount:  row.Amount,
+				Average: row.Average,
+			}
+		}
+	}
+
+	for _, groups := range [][]models.SummaryGroup{summary.ByStatus, summary.ByService, summary.ByPeriod} {
+		sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	}
+
+	return summary, nil
+}
+
+func (r *reportRepository) StatusTotals(ctx context.Context, now time.Time, terms time.Duration) ([]models.StatusTotals, error) {
+	ctx, span := tracer.Start(ctx, "ReportRepository.StatusTotals")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	const overdue = "status <> @paid AND COALESCE(due_date, date + make_interval(secs => @terms)) < @now"
+	var totals []models.StatusTotals
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT status,
+			COUNT(*) AS count,
+			COALESCE(SUM(amount), 0) AS amount,
+			COUNT(*) FILTER (WHERE `+overdue+`) AS overdue_count,
+			COALESCE(SUM(amount) FILTER (WHERE `+overdue+`), 0) AS overdue_amount
+		FROM invoices
+		GROUP BY status
+		ORDER BY status`,
+		map[string]any{"paid": models.StatusPaid, "terms": terms.Seconds(), "now": now},
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, middleware.NewInternalError("Failed to count invoices by status")
+	}
+
+	return totals, nil
+}
+
+// revenueSQL fills every (bucket, service) pair with generate_series so that
+// empty periods show up as zero, then derives running totals, the moving
+// average and the previous bucket per service with window functions. The
+// interval and window are validated and inlined; everything else is bound.
+const revenueSQL = `
+WITH buckets AS (
+	SELECT generate_series(
+		date_trunc('%[1]s', CAST(@from AS timestamptz)),
+		date_trunc('%[1]s', CAST(@to AS timestamptz) - interval '1 microsecond'),
+		interval '1 %[1]s'
+	) AS bucket
+),
+filtered AS (
+	SELECT date, service_name, amount
+	FROM invoices
+	WHERE %[3]s
+),
+services AS (
+	SELECT DISTINCT service_name FROM filtered
+),
+totals AS (
+	SELECT date_trunc('%[1]s', date) AS bucket, service_name, SUM(amount) AS amount, COUNT(*) AS count
+	FROM filtered
+	GROUP BY 1, 2
+)
+SELECT
+	b.bucket,
+	s.service_name,
+	COALESCE(t.amount, 0) AS amount,
+	COALESCE(t.count, 0) AS count,
+	SUM(COALESCE(t.amount, 0)) OVER w AS running_total,
+	AVG(COALESCE(t.amount, 0)) OVER (w ROWS BETWEEN %[2]d PRECEDING AND CURRENT ROW) AS moving_average,
+	LAG(COALESCE(t.amount, 0)) OVER w AS previous_amount
+FROM buckets b
+CROSS JOIN services s
+LEFT JOIN totals t ON t.bucket = b.bucket AND t.service_name = s.service_name
+WINDOW w AS (PARTITION BY s.service_name ORDER BY b.bucket)
+ORDER BY s.service_name, b.bucket`
+
+type revenueRow struct {
+	Bucket         time.Time
+	ServiceName    string
+	Amount         float64
+	Count          int64
+	RunningTotal   float64
+	MovingAverage  float64
+	PreviousAmount *float64
+}
+
+type comparisonRow struct {
+	CurrentAmount  float64
+	CurrentCount   int64
+	PreviousAmount float64
+	PreviousCount  int64
+}
+
+func (r *reportRepository) Revenue(ctx context.Context, filter RevenueFilter) (*models.RevenueReport, error) {
+	ctx, span := tracer.Start(ctx, "ReportRepository.Revenue")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if !IsValidInterval(filter.Interval) {
+		return nil, middleware.NewBadRequestError("Interval must be one of: day, week, month")
+	}
+	if filter.From.IsZero() || filter.To.IsZero() || !filter.From.Before(filter.To) {
+		return nil, middleware.NewBadRequestError("A valid from/to date range is required")
+	}
+	if filter.Window < 1 {
+		filter.Window = 1
+	}
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"tenant_id = @tenant", "date >= @from", "date < @to"}
+	args := map[string]interface{}{
+		"tenant": tenant,
+		"from":   filter.From,
+		"to":     filter.To,
+	}
+
+	search := filter.Search
+	if len(search) > maxSearchLen {
+		search = search[:maxSearchLen]
+	}
+	if search != "" {
+		conditions = append(conditions, "service_name ILIKE @search")
+		args["search"] = fmt.Sprintf("%%%s%%", search)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = @status")
+		args["status"] = filter.Status
+	}
+
+	query := fmt.Sprintf(revenueSQL, filter.Interval, filter.Window-1, strings.Join(conditions, " AND "))
+
+	var rows []revenueRow
+	if err := r.db.WithContext(ctx).Raw(query, args).Scan(&rows).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to build revenue series")
+	}
+
+	comparison, err := r.compare(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RevenueReport{
+		Interval:   filter.Interval,
+		From:       filter.From,
+		To:         filter.To,
+		Window:     filter.Window,
+		Series:     []models.RevenueSeries{},
+		Comparison: *comparison,
+	}
+
+	buckets, err := r.buckets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]models.RevenuePoint, len(buckets))
+	bucketIndex := make(map[time.Time]int, len(buckets))
+	for i, bucket := range buckets {
+		totals[i].Bucket = bucket
+		bucketIndex[bucket.UTC()] = i
+	}
+
+	for _, row := range rows {
+		if len(report.Series) == 0 || report.Series[len(report.Series)-1].ServiceName != row.ServiceName {
+			report.Series = append(report.Series, models.RevenueSeries{
+				ServiceName: row.ServiceName,
+				Points:      []models.RevenuePoint{},
+			})
+		}
+
+		series := &report.Series[len(report.Series)-1]
+		series.Points = append(series.Points, models.RevenuePoint{
+			Bucket:         row.Bucket,
+			Amount:         row.Amount,
+			Count:          row.Count,
+			RunningTotal:   row.RunningTotal,
+			MovingAverage:  row.MovingAverage,
+			PreviousAmount: row.PreviousAmount,
+			ChangePct:      changePct(row.Amount, row.PreviousAmount),
+		})
+
+		if i, ok := bucketIndex[row.Bucket.UTC()]; ok {
+			totals[i].Amount += row.Amount
+			totals[i].Count += row.Count
+		}
+	}
+
+	report.Total = models.RevenueSeries{Points: accumulate(totals, filter.Window)}
+
+	return report, nil
+}
+
+// buckets returns the gap-filled bucket starts on their own so that the
+// overall series is complete even when no invoice matches.
+func (r *reportRepository) buckets(ctx context.Context, filter RevenueFilter) ([]time.Time, error) {
+	query := fmt.Sprintf(`SELECT generate_series(
+		date_trunc('%[1]s', CAST(@from AS timestamptz)),
+		date_trunc('%[1]s', CAST(@to AS timestamptz) - interval '1 microsecond'),
+		interval '1 %[1]s'
+	) AS bucket`, filter.Interval)
+
+	var rows []struct {
+		Bucket time.Time
+	}
+	if err := r.db.WithContext(ctx).Raw(query, map[string]interface{}{
+		"from": filter.From,
+		"to":   filter.To,
+	}).Scan(&rows).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to build revenue buckets")
+	}
+
+	buckets := make([]time.Time, len(rows))
+	for i, row := range rows {
+		buckets[i] = row.Bucket
+	}
+	return buckets, nil
+}
+
+// compare sums the requested range and the equally long range right before
+// it in one pass.
+func (r *reportRepository) compare(ctx context.Context, filter RevenueFilter) (*models.PeriodComparison, error) {
+	length := filter.To.Sub(filter.From)
+	previousFrom := filter.From.Add(-length)
+
+	query := r.filtered(ctx, ReportFilter{
+		Search: filter.Search,
+		From:   previousFrom,
+		To:     filter.To,
+	})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var row comparisonRow
+	if err := query.Select(`
+		COALESCE(SUM(amount) FILTER (WHERE date >= ?), 0) AS current_amount,
+		COUNT(*) FILTER (WHERE date >= ?) AS current_count,
+		COALESCE(SUM(amount) FILTER (WHERE date < ?), 0) AS previous_amount,
+		COUNT(*) FILTER (WHERE date < ?) AS previous_count`,
+		filter.From, filter.From, filter.From, filter.From,
+	).Scan(&row).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to compare revenue periods")
+	}
+
+	previous := row.PreviousAmount
+	return &models.PeriodComparison{
+		CurrentFrom:    filter.From,
+		CurrentTo:      filter.To,
+		PreviousFrom:   previousFrom,
+		PreviousTo:     filter.From,
+		CurrentAmount:  row.CurrentAmount,
+		PreviousAmount: row.PreviousAmount,
+		CurrentCount:   row.CurrentCount,
+		PreviousCount:  row.PreviousCount,
+		Change:         row.CurrentAmount - row.PreviousAmount,
+		ChangePct:      changePct(row.CurrentAmount, &previous),
+	}, nil
+}
+
+// accumulate derives running totals, moving averages and the change against
+// the previous bucket for a series that only has amounts and counts.
+func accumulate(points []models.RevenuePoint, window int) []models.RevenuePoint {
+	var running, windowSum float64
+	for i := range points {
+		running += points[i].Amount
+		windowSum += points[i].Amount
+		if i >= window {
+			windowSum -= points[i-window].Amount
+		}
+
+		points[i].RunningTotal = running
+		points[i].MovingAverage = windowSum / float64(min(i+1, window))
+
+		if i > 0 {
+			previous := points[i-1].Amount
+			points[i].PreviousAmount = &previous
+			points[i].ChangePct = changePct(points[i].Amount, &previous)
+		}
+	}
+	return points
+}
+
+func changePct(current float64, previous *float64) *float64 {
+	if previous == nil || *previous == 0 {
+		return nil
+	}
+	pct := (current - *previous) / *previous * 100
+	return &pct
+}
+
+func (r *reportRepository) filtered(ctx context.Context, filter ReportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.Invoice{}).Scopes(tenantScope(ctx))
+
+	search := filter.Search
+	if len(search) > maxSearchLen {
+		search = search[:maxSearchLen]
+	}
+	if search != "" {
+		query = query.Where("service_name ILIKE ?", fmt.Sprintf("%%%s%%", search))
+	}
+
+	if !filter.From.IsZero() {
+		query = query.Where("date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("date < ?", filter.To)
+	}
+
+	return query
+}
+
+func (row summaryRow) group(key *string) models.SummaryGroup {
+	group := models.SummaryGroup{
+		Count:   row.Count,
+		Amount:  row.Amount,
+		Average: row.Average,
+	}
+	if key != nil {
+		group.Key = *key
+	}
+	return group
+}
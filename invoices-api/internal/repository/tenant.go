@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errNoTenant = middleware.NewForbiddenError("No organization selected")
+
+// tenantID returns the organization the context acts on. Tenant-owned
+// repositories refuse to run without one.
+func tenantID(ctx context.Context) (uint, error) {
+	id, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return 0, errNoTenant
+	}
+	return id, nil
+}
+
+// tenantScope restricts a query to the context's organization. When no
+// tenant is set the query fails instead of running unscoped.
+func tenantScope(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id, err := tenantID(ctx)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"},
+			Value:  id,
+		})
+	}
+}
+
+// invoiceCacheKey includes the organization so that an ID from one tenant
+// never hits another tenant's cache entry.
+func invoiceCacheKey(tenant, id uint) string {
+	return fmt.Sprintf("invoice:%d:%d", tenant, id)
+}
+
+// invoiceListCacheKey identifies a page of a listing. The search term is
+// escaped so that it cannot run into the other components.
+func invoiceListCacheKey(tenant uint, searchTerm string, params QueryParams) string {
+	return fmt.Sprintf("invoice_list:%d:%s:%d:%d:%s:%s",
+		tenant, url.QueryEscape(searchTerm), params.Page, params.Limit, params.SortBy, params.SortDir)
+}
+
+// invoiceListTag groups every cached listing of a tenant, which any write to
+// its invoices makes stale.
+func invoiceListTag(tenant uint) string {
+	return fmt.Sprintf("invoices:t%d", tenant)
+}
+
+// touchInvoices records on the caller's transaction that the tenant's
+// invoices changed. The organization's row stays locked until the
+// transaction ends, so concurrent writes to its invoices commit in the order
+// of their timestamps.
+func touchInvoices(tx *gorm.DB, tenant uint) error {
+	if err := tx.Model(&models.Organization{}).
+		Where("id = ?", tenant).
+		UpdateColumn("invoices_changed_at", gorm.Expr("clock_timestamp()")).Error; err != nil {
+		return middleware.NewInternalError("Failed to update organization")
+	}
+	return nil
+}
+
+// countInvoices counts the rows of query, a scoped invoice query, and reads
+// the time the tenant's invoices last changed in the same statement, so that
+// both come from the same snapshot.
+func countInvoices(ctx context.Context, query *gorm.DB) (int64, time.Time, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var result struct {
+		Total     int64
+		ChangedAt time.Time
+	}
+	err = query.Select("count(*) AS total, (SELECT invoices_changed_at FROM organizations WHERE id = ?) AS changed_at", tenant).
+		Scan(&result).Error
+	return result.Total, result.ChangedAt, err
+}
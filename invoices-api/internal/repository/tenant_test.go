@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"invoices-api/internal/cache"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/database/dbtest"
+	"invoices-api/pkg/middleware"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tenants are two organizations with one invoice each. Both invoices have
+// the same number, which is unique per organization only.
+type tenants struct {
+	db            *gorm.DB
+	acme, globex  context.Context
+	acmeInvoice   *models.Invoice
+	globexInvoice *models.Invoice
+}
+
+func setupTenants(t *testing.T) *tenants {
+	t.Helper()
+
+	db := dbtest.Open(t)
+	acme := dbtest.Organization(t, db, "acme")
+	globex := dbtest.Organization(t, db, "globex")
+
+	ts := &tenants{
+		db:     db,
+		acme:   middleware.WithTenant(context.Background(), acme.ID),
+		globex: middleware.WithTenant(context.Background(), globex.ID),
+	}
+
+	repo := NewInvoiceRepository(db, nil, 0, false)
+	ts.acmeInvoice = createInvoice(t, repo, ts.acme, "Acme consulting", 1000)
+	ts.globexInvoice = createInvoice(t, repo, ts.globex, "Globex hosting", 250)
+	return ts
+}
+
+func createInvoice(t *testing.T, repo InvoiceRepository, ctx context.Context, service string, amount float64) *models.Invoice {
+	t.Helper()
+
+	invoice := &models.Invoice{
+		ServiceName:   service,
+		InvoiceNumber: 1,
+		Date:          time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Amount:        amount,
+		Status:        "Pending",
+	}
+	if err := repo.Create(ctx, invoice); err != nil {
+		t.Fatalf("failed to create invoice: %v", err)
+	}
+	return invoice
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var e *middleware.ErrorResponse
+	if !errors.As(err, &e) || e.Code != status {
+		t.Fatalf("got error %v, want status %d", err, status)
+	}
+}
+
+func TestInvoiceRepositoryTenantIsolation(t *testing.T) {
+	ts := setupTenants(t)
+	// The cache is shared by both tenants, as it is in the server.
+	repo := NewInvoiceRepository(ts.db, cache.NewMemory(100), time.Minute, false)
+	params := NewQueryParams(1, 10, "", "")
+
+	t.Run("GetByID", func(t *testing.T) {
+		if _, err := repo.GetByID(ts.acme, ts.acmeInvoice.ID); err != nil {
+			t.Fatal(err)
+		}
+		_, err := repo.GetByID(ts.globex, ts.acmeInvoice.ID)
+		assertStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		list, err := repo.GetAll(ts.globex, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.Total != 1 || len(list.Invoices) != 1 || list.Invoices[0].ID != ts.globexInvoice.ID {
+			t.Fatalf("got %d of %d invoices %v, want only the globex invoice", len(list.Invoices), list.Total, list.Invoices)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		list, err := repo.Search(ts.globex, "Acme", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.Total != 0 || len(list.Invoices) != 0 {
+			t.Fatalf("search found %v in another organization", list.Invoices)
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		var exported []uint
+		if err := repo.Export(ts.globex, "", params, func(invoice *models.Invoice) error {
+			exported = append(exported, invoice.ID)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(exported) != 1 || exported[0] != ts.globexInvoice.ID {
+			t.Fatalf("exported %v, want only %d", exported, ts.globexInvoice.ID)
+		}
+	})
+
+	t.Run("ExistingInvoiceNumbers", func(t *testing.T) {
+		// Both organizations use number 1; number 2 exists in neither.
+		existing, err := repo.ExistingInvoiceNumbers(ts.globex, []int{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !existing[1] || existing[2] {
+			t.Fatalf("got %v, want only 1", existing)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		changed := *ts.acmeInvoice
+		changed.Amount = 1
+		assertStatus(t, repo.Update(ts.globex, &changed), http.StatusNotFound)
+
+		stored, err := repo.GetByID(ts.acme, ts.acmeInvoice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Amount != ts.acmeInvoice.Amount {
+			t.Fatalf("amount changed to %v by another organization", stored.Amount)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assertStatus(t, repo.Delete(ts.globex, ts.acmeInvoice.ID), http.StatusNotFound)
+
+		if _, err := repo.GetByID(ts.acme, ts.acmeInvoice.ID); err != nil {
+			t.Fatalf("invoice deleted by another organization: %v", err)
+		}
+	})
+
+	t.Run("NoTenant", func(t *testing.T) {
+		_, err := repo.GetAll(context.Background(), params)
+		assertStatus(t, err, http.StatusForbidden)
+	})
+}
+
+func TestReportRepositoryTenantIsolation(t *testing.T) {
+	ts := setupTenants(t)
+	repo := NewReportRepository(ts.db)
+
+	summary, err := repo.Summary(ts.globex, ReportFilter{Period: "month"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Totals.Count != 1 || summary.Totals.Amount != ts.globexInvoice.Amount {
+		t.Fatalf("got totals %+v, want only the globex invoice", summary.Totals)
+	}
+
+	revenue, err := repo.Revenue(ts.globex, RevenueFilter{
+		ReportFilter: ReportFilter{
+			From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Interval: "month",
+		Window:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	for _, point := range revenue.Total.Points {
+		count += point.Count
+	}
+	if count != 1 {
+		t.Fatalf("revenue counts %d invoices, want only the globex invoice", count)
+	}
+}
+
+func TestWebhookRepositoryTenantIsolation(t *testing.T) {
+	ts := setupTenants(t)
+	repo := NewWebhookRepository(ts.db)
+
+	sub := &models.WebhookSubscription{URL: "https://hooks.example.com", Secret: "secret", Events: []string{models.EventAll}, Active: true}
+	if err := repo.CreateSubscription(ts.acme, sub); err != nil {
+		t.Fatal(err)
+	}
+	delivery := &models.WebhookDelivery{
+		TenantID:       sub.TenantID,
+		SubscriptionID: sub.ID,
+		EventID:        "evt_1",
+		Event:          models.EventInvoiceCreated,
+		Payload:        []byte(`{}`),
+		Status:         models.DeliveryFailed,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := ts.db.Create(delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	subs, err := repo.ListSubscriptions(ts.globex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 0 {
+		t.Fatalf("listed %v from another organization", subs)
+	}
+
+	_, err = repo.GetSubscription(ts.globex, sub.ID)
+	assertStatus(t, err, http.StatusNotFound)
+
+	deliveries, total, err := repo.ListDeliveries(ts.globex, sub.ID, "", NewQueryParams(1, 10, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || len(deliveries) != 0 {
+		t.Fatalf("listed %v from another organization", deliveries)
+	}
+
+	_, err = repo.RetryDelivery(ts.globex, sub.ID, delivery.ID)
+	assertStatus(t, err, http.StatusNotFound)
+
+	assertStatus(t, repo.DeleteSubscription(ts.globex, sub.ID), http.StatusNotFound)
+	if _, err := repo.GetSubscription(ts.acme, sub.ID); err != nil {
+		t.Fatalf("subscription deleted by another organization: %v", err)
+	}
+}
+
+func TestAPIKeyRepositoryTenantIsolation(t *testing.T) {
+	ts := setupTenants(t)
+	users := NewUserRepository(ts.db)
+	repo := NewAPIKeyRepository(ts.db)
+
+	acme, _ := middleware.TenantFromContext(ts.acme)
+	globex, _ := middleware.TenantFromContext(ts.globex)
+	owner := &models.User{TenantID: acme, Email: "owner@acme.example", PasswordHash: "x", Role: middleware.RoleAdmin}
+	other := &models.User{TenantID: globex, Email: "admin@globex.example", PasswordHash: "x", Role: middleware.RoleAdmin}
+	for _, user := range []*models.User{owner, other} {
+		if err := users.Create(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := &models.APIKey{TenantID: owner.TenantID, UserID: owner.ID, Name: "cron", Prefix: "inv_abc", KeyHash: "hash", Scopes: []string{"invoices:read"}}
+	if err := repo.Create(ts.acme, key); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := repo.ListByUser(ts.globex, other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("listed %v of another organization", keys)
+	}
+
+	assertStatus(t, repo.Revoke(ts.globex, key.ID, other.ID), http.StatusNotFound)
+}
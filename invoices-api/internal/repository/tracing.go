@@ -0,0 +1,7 @@
+package repository
+
+import "go.opentelemetry.io/otel"
+
+// tracer records a span for every repository method, between the request
+// span and the spans of its queries.
+var tracer = otel.Tracer("invoices-api/internal/repository")
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{
+		db: db,
+	}
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.GetByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("User not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch user")
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.GetByEmail")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", strings.ToLower(email)).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("User not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch user")
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, span := tracer.Start(ctx, "UserRepository.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	user.Email = strings.ToLower(user.Email)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
+			return middleware.NewInternalError("Failed to check duplicate email")
+		}
+		if count > 0 {
+			return middleware.NewBadRequestError("A user with this email already exists")
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			return middleware.NewInternalError("Failed to create user")
+		}
+
+		return nil
+	})
+}
+
+func (r *userRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.CountByRole")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", role).Count(&count).Error; err != nil {
+		return 0, middleware.NewInternalError("Failed to count users")
+	}
+
+	return count, nil
+}
+
+func (r *userRepository) SetRole(ctx context.Context, id uint, role string) error {
+	ctx, span := tracer.Start(ctx, "UserRepository.SetRole")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return middleware.NewInternalError("Failed to update user role")
+	}
+	if result.RowsAffected == 0 {
+		return middleware.NewNotFoundError("User not found")
+	}
+
+	return nil
+}
+
+func (r *userRepository) TouchLastLogin(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "UserRepository.TouchLastLogin")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		UpdateColumn("last_login_at", time.Now()).Error; err != nil {
+		return middleware.NewInternalError("Failed to update user")
+	}
+
+	return nil
+}
+
+func (r *userRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ctx, span := tracer.Start(ctx, "UserRepository.CreateRefreshToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return middleware.NewInternalError("Failed to store refresh token")
+	}
+
+	return nil
+}
+
+func (r *userRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.GetRefreshToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("Refresh token not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch refresh token")
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken marks a token as used. It reports false when the token
+// was already revoked, which lets callers detect refresh token reuse.
+func (r *userRepository) RevokeRefreshToken(ctx context.Context, id uint) (bool, error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.RevokeRefreshToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, middleware.NewInternalError("Failed to revoke refresh token")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) RevokeAllRefreshTokens(ctx context.Context, userID uint) error {
+	ctx, span := tracer.Start(ctx, "UserRepository.RevokeAllRefreshTokens")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		UpdateColumn("revoked_at", time.Now()).Error; err != nil {
+		return middleware.NewInternalError("Failed to revoke refresh tokens")
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"invoices-api/internal/models"
+	"invoices-api/pkg/middleware"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.CreateSubscription")
+	defer span.End()
+
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	sub.TenantID = tenant
+	if err := r.db.WithContext(ctx).Create(sub).Error; err != nil {
+		return middleware.NewInternalError("Failed to create webhook")
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.ListSubscriptions")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	subs := []models.WebhookSubscription{}
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).Order("id").Find(&subs).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch webhooks")
+	}
+
+	return subs, nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.GetSubscription")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var sub models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).First(&sub, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, middleware.NewNotFoundError("Webhook not found")
+		}
+		return nil, middleware.NewInternalError("Failed to fetch webhook")
+	}
+
+	return &sub, nil
+}
+
+// DeleteSubscription also removes the subscription's deliveries through the
+// foreign key.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.DeleteSubscription")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return middleware.NewInternalError("Failed to delete webhook")
+	}
+	if result.RowsAffected == 0 {
+		return middleware.NewNotFoundError("Webhook not found")
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uint, status string, params QueryParams) ([]models.WebhookDelivery, int64, error) {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.ListDeliveries")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&models.WebhookDelivery{}).
+			Scopes(tenantScope(ctx)).
+			Where("subscription_id = ?", subscriptionID)
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, middleware.NewInternalError("Failed to count webhook deliveries")
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := r.db.WithContext(ctx).Scopes(filter).
+		Order("id DESC").
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, middleware.NewInternalError("Failed to fetch webhook deliveries")
+	}
+
+	return deliveries, total, nil
+}
+
+// RetryDelivery queues a delivery again with a fresh set of attempts.
+func (r *webhookRepository) RetryDelivery(ctx context.Context, subscriptionID, id uint) (*models.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.RetryDelivery")
+	defer span.End()
+
+	if _, err := tenantID(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WebhookDelivery{}).
+			Scopes(tenantScope(ctx)).
+			Where("id = ? AND subscription_id = ?", id, subscriptionID).
+			Updates(map[string]interface{}{
+				"status":          models.DeliveryPending,
+				"attempts":        0,
+				"next_attempt_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return middleware.NewNotFoundError("Webhook delivery not found")
+		}
+
+		return tx.First(&delivery, id).Error
+	})
+	if err != nil {
+		if e, ok := err.(*middleware.ErrorResponse); ok {
+			return nil, e
+		}
+		return nil, middleware.NewInternalError("Failed to retry webhook delivery")
+	}
+
+	return &delivery, nil
+}
+
+// ClaimDueDeliveries picks pending deliveries that are due, across all
+// tenants, and pushes their next attempt out by lease so that other
+// instances skip them while they are being sent.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.ClaimDueDeliveries")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var ids []uint
+	if err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		now.Add(lease), models.DeliveryPending, now, limit,
+	).Scan(&ids).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to claim webhook deliveries")
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := r.db.WithContext(ctx).Preload("Subscription").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&deliveries).Error; err != nil {
+		return nil, middleware.NewInternalError("Failed to fetch webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) SaveDeliveryResult(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.SaveDeliveryResult")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"last_attempt_at": delivery.LastAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"response_body":   delivery.ResponseBody,
+			"last_error":      delivery.LastError,
+		}).Error; err != nil {
+		return middleware.NewInternalError("Failed to update webhook delivery")
+	}
+
+	return nil
+}
+
+// EnqueueEvent queues a delivery of event for each active subscription of
+// its tenant that wants it. Deliveries are unique per subscription and
+// event, so publishing the same event again queues nothing.
+func (r *webhookRepository) EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error {
+	ctx, span := tracer.Start(ctx, "WebhookRepository.EnqueueEvent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	db := r.db.WithContext(ctx)
+
+	var subs []models.WebhookSubscription
+	if err := db.Where("tenant_id = ? AND active = ?", event.TenantID, true).Find(&subs).Error; err != nil {
+		return middleware.NewInternalError("Failed to fetch webhooks")
+	}
+
+	var payload json.RawMessage
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, sub := range subs {
+		if !sub.Matches(event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			data, err := json.Marshal(models.WebhookEvent{
+				ID:        event.EventID,
+				Type:      event.Type,
+				CreatedAt: event.CreatedAt,
+				TenantID:  event.TenantID,
+				Data:      event.Payload,
+			})
+			if err != nil {
+				return middleware.NewInternalError("Failed to encode webhook payload")
+			}
+			payload = data
+		}
+
+		deliveries = append(deliveries, models.WebhookDelivery{
+			TenantID:       event.TenantID,
+			SubscriptionID: sub.ID,
+			EventID:        event.EventID,
+			Event:          event.Type,
+			Payload:        payload,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		return middleware.NewInternalError("Failed to queue webhooks")
+	}
+
+	return nil
+}
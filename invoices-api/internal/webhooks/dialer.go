@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// reservedPrefixes are not routed on the internet but are not covered by the
+// netip predicates either.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64 addresses embed an IPv4 address that may be private.
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// IsPublicAddress reports whether webhooks may be sent to addr. Loopback,
+// private, link-local (such as the cloud metadata service at
+// 169.254.169.254), multicast and reserved addresses are not public.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicOnly is a net.Dialer Control function. It runs after the host name
+// has been resolved, for every address that is tried, so a subscriber cannot
+// get past it with a DNS name pointing at an internal address, nor by
+// changing what the name resolves to after the subscription was created.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook target %s: %w", address, err)
+	}
+	if !IsPublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("webhook target %s is not a public address", addrPort.Addr())
+	}
+	return nil
+}
+
+// newTransport returns the transport deliveries are sent with. Unless
+// allowPrivate is set it only connects to public addresses, and ignores
+// proxy settings, since a proxy would connect on the dispatcher's behalf.
+func newTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = publicOnly
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
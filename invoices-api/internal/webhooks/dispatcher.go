@@ -0,0 +1,191 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultTimeout      = 10 * time.Second
+	defaultMaxAttempts  = 10
+	batchSize           = 50
+	maxResponseBody     = 2048
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+type Config struct {
+	PollInterval time.Duration
+	// Timeout bounds a single delivery attempt.
+	Timeout     time.Duration
+	MaxAttempts int
+	// AllowPrivateNetworks lets deliveries reach loopback, private and
+	// link-local addresses, e.g. for receivers on the same host during
+	// development. Otherwise they are refused when connecting.
+	AllowPrivateNetworks bool
+}
+
+// Dispatcher sends queued deliveries. Several instances may run against the
+// same database; each delivery is claimed by one of them at a time.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	config Config
+}
+
+func NewDispatcher(repo repository.WebhookRepository, config Config) *Dispatcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Transport: newTransport(config.AllowPrivateNetworks),
+			Timeout:   config.Timeout,
+			// Redirects are not followed so that a subscriber cannot bounce
+			// signed payloads to another host.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+	}
+}
+
+// Run delivers due webhooks until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep going while full batches come back so that a backlog
+			// drains without waiting for the next tick.
+			for d.DeliverDue(ctx) == batchSize && ctx.Err() == nil {
+			}
+		}
+	}
+}
+
+// DeliverDue sends one batch of due deliveries and returns how many were
+// attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) int {
+	// The lease must outlast a full batch of attempts, after which the
+	// deliveries are picked up again if this instance died midway.
+	lease := time.Duration(batchSize)*d.config.Timeout + time.Minute
+
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, time.Now(), batchSize, lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim webhook deliveries", "error", err)
+		return 0
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+
+		delivery := &deliveries[i]
+		d.attempt(ctx, delivery)
+
+		if err := d.repo.SaveDeliveryResult(context.WithoutCancel(ctx), delivery); err != nil {
+			slog.ErrorContext(ctx, "Failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+	}
+
+	return len(deliveries)
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.LastError = ""
+
+	sub := delivery.Subscription
+	if sub == nil || !sub.Active {
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "subscription is disabled"
+		return
+	}
+
+	status, body, err := d.send(ctx, sub, delivery, now)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+
+	switch {
+	case err != nil:
+		delivery.LastError = err.Error()
+	case status >= 200 && status < 300:
+		delivery.Status = models.DeliverySucceeded
+		return
+	default:
+		delivery.LastError = fmt.Sprintf("unexpected status %d", status)
+	}
+
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		return
+	}
+
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts))
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "invoices-api-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return resp.StatusCode, string(body), nil
+}
+
+// Backoff returns the delay before the next attempt after the given number
+// of failed attempts: 30s doubling up to 6h, with up to 20% jitter so that
+// deliveries failing together do not retry together.
+func Backoff(attempts int) time.Duration {
+	delay := maxBackoff
+	if attempts < 1 {
+		delay = baseBackoff
+	} else if attempts < 20 {
+		delay = min(baseBackoff<<(attempts-1), maxBackoff)
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay) / 5))
+	return delay + jitter
+}
@@ -0,0 +1,237 @@
+package webhooks
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRepository hands out its deliveries once and records the results.
+type fakeRepository struct {
+	repository.WebhookRepository
+	due   []models.WebhookDelivery
+	saved []models.WebhookDelivery
+}
+
+func (r *fakeRepository) ClaimDueDeliveries(context.Context, time.Time, int, time.Duration) ([]models.WebhookDelivery, error) {
+	due := r.due
+	r.due = nil
+	return due, nil
+}
+
+func (r *fakeRepository) SaveDeliveryResult(_ context.Context, delivery *models.WebhookDelivery) error {
+	r.saved = append(r.saved, *delivery)
+	return nil
+}
+
+const testSecret = "whsec_test"
+
+func newDelivery(url string, attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:       1,
+		EventID:  "evt_1",
+		Event:    models.EventInvoicePaid,
+		Payload:  []byte(`{"id":"evt_1","type":"invoice.paid"}`),
+		Status:   models.DeliveryPending,
+		Attempts: attempts,
+		Subscription: &models.WebhookSubscription{
+			ID:     1,
+			URL:    url,
+			Secret: testSecret,
+			Active: true,
+		},
+	}
+}
+
+// deliver runs one dispatch of delivery and returns the recorded result.
+func deliver(t *testing.T, config Config, delivery models.WebhookDelivery) models.WebhookDelivery {
+	t.Helper()
+
+	repo := &fakeRepository{due: []models.WebhookDelivery{delivery}}
+	if n := NewDispatcher(repo, config).DeliverDue(context.Background()); n != 1 {
+		t.Fatalf("attempted %d deliveries, want 1", n)
+	}
+	if len(repo.saved) != 1 {
+		t.Fatalf("recorded %d results, want 1", len(repo.saved))
+	}
+	return repo.saved[0]
+}
+
+func TestDispatcherSignsRequests(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	delivery := newDelivery(server.URL, 0)
+	result := deliver(t, Config{AllowPrivateNetworks: true}, delivery)
+
+	if received == nil {
+		t.Fatal("no request received")
+	}
+	if got := received.Header.Get(HeaderEvent); got != delivery.Event {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, delivery.Event)
+	}
+	if got := received.Header.Get(HeaderEventID); got != delivery.EventID {
+		t.Errorf("%s = %q, want %q", HeaderEventID, got, delivery.EventID)
+	}
+	if string(body) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", body, delivery.Payload)
+	}
+	signature := received.Header.Get(HeaderSignature)
+	if !Verify(testSecret, signature, body, time.Minute, time.Now()) {
+		t.Errorf("signature %q does not verify", signature)
+	}
+	if Verify("whsec_other", signature, body, time.Minute, time.Now()) {
+		t.Error("signature verifies with another secret")
+	}
+
+	if result.Status != models.DeliverySucceeded || result.Attempts != 1 || result.ResponseStatus != http.StatusOK || result.ResponseBody != "ok" {
+		t.Errorf("got status %s after %d attempts with response %d %q, want succeeded after 1 with 200 \"ok\"",
+			result.Status, result.Attempts, result.ResponseStatus, result.ResponseBody)
+	}
+}
+
+func TestVerifyRejectsOldSignatures(t *testing.T) {
+	body := []byte(`{}`)
+	signed := time.Now().Add(-10 * time.Minute)
+	signature := Sign(testSecret, signed, body)
+
+	if Verify(testSecret, signature, body, 5*time.Minute, time.Now()) {
+		t.Error("a signature older than the tolerance verifies")
+	}
+	if !Verify(testSecret, signature, body, 5*time.Minute, signed) {
+		t.Error("a fresh signature does not verify")
+	}
+	if Verify(testSecret, signature, []byte(`{"changed":true}`), 5*time.Minute, signed) {
+		t.Error("a signature verifies a different body")
+	}
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	config := Config{MaxAttempts: 3, AllowPrivateNetworks: true}
+
+	before := time.Now()
+	result := deliver(t, config, newDelivery(server.URL, 1))
+	if result.Status != models.DeliveryPending || result.Attempts != 2 {
+		t.Fatalf("got status %s after %d attempts, want pending after 2", result.Status, result.Attempts)
+	}
+	if result.ResponseStatus != http.StatusServiceUnavailable || result.LastError != "unexpected status 503" {
+		t.Errorf("got response %d and error %q", result.ResponseStatus, result.LastError)
+	}
+	// The second failure waits 60s plus up to 20% jitter.
+	if wait := result.NextAttemptAt.Sub(before); wait < time.Minute || wait > 73*time.Second {
+		t.Errorf("next attempt in %s, want between 60s and 72s", wait)
+	}
+
+	result = deliver(t, config, newDelivery(server.URL, 2))
+	if result.Status != models.DeliveryFailed || result.Attempts != 3 {
+		t.Errorf("got status %s after %d attempts, want failed after 3", result.Status, result.Attempts)
+	}
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect was followed")
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer server.Close()
+
+	result := deliver(t, Config{AllowPrivateNetworks: true}, newDelivery(server.URL, 0))
+	if result.Status != models.DeliveryPending || result.ResponseStatus != http.StatusFound {
+		t.Errorf("got status %s with response %d, want pending with 302", result.Status, result.ResponseStatus)
+	}
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback address")
+	}))
+	defer server.Close()
+
+	// localhost resolves to a loopback address, which is only seen when
+	// connecting.
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{server.URL, url, "http://169.254.169.254/latest/meta-data"} {
+		result := deliver(t, Config{Timeout: time.Second}, newDelivery(target, 0))
+		if result.Status != models.DeliveryPending || !strings.Contains(result.LastError, "not a public address") {
+			t.Errorf("%s: got status %s with error %q, want a refused connection", target, result.Status, result.LastError)
+		}
+	}
+}
+
+func TestDispatcherSkipsInactiveSubscriptions(t *testing.T) {
+	delivery := newDelivery("https://hooks.example.com", 0)
+	delivery.Subscription.Active = false
+
+	result := deliver(t, Config{}, delivery)
+	if result.Status != models.DeliveryFailed || result.LastError != "subscription is disabled" {
+		t.Errorf("got status %s with error %q", result.Status, result.LastError)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		base     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, maxBackoff},
+		{50, maxBackoff},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := Backoff(tt.attempts); got < tt.base || got >= tt.base+tt.base/5 {
+				t.Errorf("Backoff(%d) = %s, want %s plus up to 20%%", tt.attempts, got, tt.base)
+			}
+		}
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":       true,
+		"2606:2800:220:1::1":  true,
+		"127.0.0.1":           false,
+		"::1":                 false,
+		"10.1.2.3":            false,
+		"172.16.0.1":          false,
+		"192.168.1.1":         false,
+		"169.254.169.254":     false,
+		"fe80::1":             false,
+		"fd00::1":             false,
+		"100.64.0.1":          false,
+		"0.0.0.0":             false,
+		"::":                  false,
+		"224.0.0.1":           false,
+		"::ffff:127.0.0.1":    false,
+		"::ffff:93.184.216.3": true,
+		"64:ff9b::a00:1":      false,
+	}
+	for address, want := range tests {
+		if got := IsPublicAddress(netip.MustParseAddr(address)); got != want {
+			t.Errorf("IsPublicAddress(%s) = %t, want %t", address, got, want)
+		}
+	}
+}
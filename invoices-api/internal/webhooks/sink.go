@@ -0,0 +1,27 @@
+package webhooks
+
+import (
+	"context"
+	"invoices-api/internal/models"
+	"invoices-api/internal/repository"
+)
+
+// Sink queues a webhook delivery of each published event for every matching
+// subscription. Register it with the outbox relay.
+type Sink struct {
+	repo repository.WebhookRepository
+}
+
+func NewSink(repo repository.WebhookRepository) *Sink {
+	return &Sink{
+		repo: repo,
+	}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+func (s *Sink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	return s.repo.EnqueueEvent(ctx, event)
+}
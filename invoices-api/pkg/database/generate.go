@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"invoices-api/internal/models"
+	"math/rand"
+	"time"
+)
+
+// GenerateOptions controls GenerateInvoices. The same options always produce
+// the same invoices.
+type GenerateOptions struct {
+	Count int
+	// Seed initializes the random number generator.
+	Seed int64
+	// FirstNumber is the invoice number of the first invoice; the others
+	// follow consecutively.
+	FirstNumber int
+	// Start and Days define the range invoice dates are picked from.
+	Start time.Time
+	Days  int
+}
+
+const (
+	DefaultGenerateSeed        = 1
+	DefaultGenerateFirstNumber = 100000
+	DefaultGenerateDays        = 365
+)
+
+var (
+	generatedServices = []string{
+		"DMP Service", "SSP Service", "DSP Service", "DDP Service",
+		"Hosting", "Consulting", "Support Plan", "Data Export",
+	}
+	// Weighted so that generated data has roughly the mix of a real ledger.
+	generatedStatuses = []string{"Paid", "Paid", "Paid", "Pending", "Pending", "Unpaid"}
+)
+
+// GenerateInvoices returns fake invoices for load tests.
+func GenerateInvoices(opts GenerateOptions) ([]models.Invoice, error) {
+	if opts.Count < 1 {
+		return nil, errors.New("count must be at least 1")
+	}
+	if opts.FirstNumber < 1 {
+		return nil, fmt.Errorf("first invoice number must be at least 1, got %d", opts.FirstNumber)
+	}
+	if opts.Days < 1 {
+		opts.Days = DefaultGenerateDays
+	}
+	if opts.Start.IsZero() {
+		opts.Start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	invoices := make([]models.Invoice, opts.Count)
+	for i := range invoices {
+		date := opts.Start.AddDate(0, 0, rng.Intn(opts.Days))
+		// Between 10.00 and 5000.00, in whole cents.
+		amount := float64(1000+rng.Intn(499001)) / 100
+
+		invoices[i] = models.Invoice{
+			InvoiceNumber: opts.FirstNumber + i,
+			ServiceName:   generatedServices[rng.Intn(len(generatedServices))],
+			Date:          date,
+			Amount:        amount,
+			Status:        generatedStatuses[rng.Intn(len(generatedStatuses))],
+		}
+	}
+	return invoices, nil
+}
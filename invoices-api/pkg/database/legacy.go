@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"log/slog"
+)
+
+// baselineVersion is the migration that reproduces the schema GORM
+// AutoMigrate used to create.
+const baselineVersion = 1
+
+// legacySchema brings a schema that AutoMigrate set up to the baseline. It is
+// fixed SQL rather than AutoMigrate with the current models, so that it keeps
+// producing the baseline as the models move on.
+//
+//go:embed legacy.sql
+var legacySchema string
+
+// adoptLegacySchema brings a database that AutoMigrate set up to the
+// baseline schema and records the baseline as applied, so that only the
+// migrations after it run. Fresh databases and databases that are already
+// versioned are left alone.
+func (m *Migrator) adoptLegacySchema(ctx context.Context, conn *sql.Conn) error {
+	var versioned, legacy bool
+	if err := conn.QueryRowContext(ctx, `SELECT
+		EXISTS (SELECT 1 FROM schema_migrations),
+		to_regclass('invoices') IS NOT NULL`).Scan(&versioned, &legacy); err != nil {
+		return err
+	}
+	if versioned || !legacy {
+		return nil
+	}
+
+	baseline := m.migrations[0]
+	if baseline.Version != baselineVersion {
+		return fmt.Errorf("baseline migration %d is missing", baselineVersion)
+	}
+
+	slog.Info("Adopting existing database schema at the baseline migration")
+
+	if err := run(ctx, conn, legacySchema, func(exec execer) error {
+		_, err := exec.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", baseline.Version, baseline.Name)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to adopt legacy schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+-- Brings a database set up by GORM AutoMigrate, as of any earlier version,
+-- to the schema of 0001_initial_schema. Tables and columns that version did
+-- not have yet are created; rows from before multi-tenancy are assigned to
+-- the default organization. Every statement is safe to run on a schema that
+-- is already complete.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    slug text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_slug ON organizations (slug);
+
+INSERT INTO organizations (name, slug, created_at, updated_at)
+VALUES ('Default', 'default', now(), now())
+ON CONFLICT (slug) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint,
+    service_name text NOT NULL,
+    invoice_number bigint,
+    date timestamptz,
+    amount decimal,
+    status text,
+    customer_email text,
+    due_date timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint,
+    email text NOT NULL,
+    name text,
+    password_hash text NOT NULL,
+    role text NOT NULL DEFAULT 'viewer',
+    active boolean NOT NULL DEFAULT true,
+    last_login_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    prefix text NOT NULL,
+    key_hash text NOT NULL,
+    scopes text NOT NULL,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint NOT NULL,
+    key text NOT NULL,
+    fingerprint text NOT NULL,
+    completed boolean NOT NULL DEFAULT false,
+    status_code bigint,
+    content_type text,
+    body bytea,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint NOT NULL,
+    url text NOT NULL,
+    description text,
+    secret text NOT NULL,
+    events text NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint NOT NULL,
+    subscription_id bigint NOT NULL,
+    event_id text NOT NULL,
+    event text NOT NULL,
+    payload jsonb NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    last_attempt_at timestamptz,
+    response_status bigint,
+    response_body text,
+    last_error text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id)
+        REFERENCES webhook_subscriptions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial PRIMARY KEY,
+    event_id text NOT NULL,
+    tenant_id bigint NOT NULL,
+    aggregate_type text NOT NULL,
+    aggregate_id bigint NOT NULL,
+    type text NOT NULL,
+    payload jsonb NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    last_error text,
+    published_at timestamptz,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS invoice_emails (
+    id bigserial PRIMARY KEY,
+    tenant_id bigint NOT NULL,
+    invoice_id bigint NOT NULL,
+    kind text NOT NULL,
+    reminder_level bigint NOT NULL DEFAULT 0,
+    recipient text NOT NULL,
+    subject text NOT NULL,
+    status text NOT NULL,
+    error text,
+    message_id text,
+    created_at timestamptz
+);
+
+-- Columns added to existing tables over time.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'viewer';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS customer_email text;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS due_date timestamptz;
+
+-- Rows from before multi-tenancy belong to the default organization.
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tenant_id bigint;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id bigint;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id bigint;
+
+UPDATE invoices SET tenant_id = (SELECT id FROM organizations WHERE slug = 'default') WHERE tenant_id IS NULL;
+UPDATE users SET tenant_id = (SELECT id FROM organizations WHERE slug = 'default') WHERE tenant_id IS NULL;
+UPDATE api_keys SET tenant_id = (SELECT id FROM organizations WHERE slug = 'default') WHERE tenant_id IS NULL;
+
+ALTER TABLE invoices ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE api_keys ALTER COLUMN tenant_id SET NOT NULL;
+
+-- Invoice numbers used to be unique across the whole table; they are now
+-- unique per tenant. Both names Postgres and GORM may have given the old
+-- constraint are dropped.
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_invoice_number_key;
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS uni_invoices_invoice_number;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_tenant_invoice_number ON invoices (tenant_id, invoice_number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users (tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_tenant_key ON idempotency_keys (tenant_id, key);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant_id ON webhook_subscriptions (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_id ON webhook_deliveries (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_tenant_id ON outbox_events (tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_event_id ON outbox_events (event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_status ON outbox_events (status);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_emails_invoice_id ON invoice_emails (invoice_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_emails_tenant_id ON invoice_emails (tenant_id);
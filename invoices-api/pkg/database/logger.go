@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"invoices-api/pkg/logging"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DefaultSlowQuery is the duration above which queries are logged as slow.
+const DefaultSlowQuery = 200 * time.Millisecond
+
+// slogLogger writes GORM's logs through slog with the query's context, so
+// that they carry the request ID. Failed queries are logged at the error
+// level, slow ones at the warn level and all others, with logger.Info, at
+// the debug level.
+type slogLogger struct {
+	level     logger.LogLevel
+	slowQuery time.Duration
+}
+
+// NewLogger returns a GORM logger reporting what level selects.
+func NewLogger(level logger.LogLevel, slowQuery time.Duration) logger.Interface {
+	if slowQuery <= 0 {
+		slowQuery = DefaultSlowQuery
+	}
+	return &slogLogger{level: level, slowQuery: slowQuery}
+}
+
+func (l *slogLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copy := *l
+	copy.level = level
+	return &copy
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "duration_ms", logging.Milliseconds(elapsed), "error", err)
+	case elapsed > l.slowQuery && l.level >= logger.Warn:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration_ms", logging.Milliseconds(elapsed))
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		slog.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration_ms", logging.Milliseconds(elapsed))
+	}
+}
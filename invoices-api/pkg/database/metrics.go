@@ -0,0 +1,19 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// RegisterPoolMetrics exports the connection pool statistics of the primary
+// as the go_sql_* metrics with db_name="primary".
+func RegisterPoolMetrics(db *gorm.DB, registerer prometheus.Registerer) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database pool: %w", err)
+	}
+	return registerer.Register(collectors.NewDBStatsCollector(sqlDB, "primary"))
+}
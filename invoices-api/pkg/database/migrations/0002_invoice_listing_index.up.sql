@@ -0,0 +1,4 @@
+-- migrate:no-transaction
+-- Invoice lists and reports filter by tenant and date. The index is built
+-- concurrently so that writes are not blocked on large tables.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_invoices_tenant_date ON invoices (tenant_id, date);
@@ -0,0 +1,14 @@
+-- Refresh tokens record the organization of the session they belong to.
+-- Legacy databases adopted at the baseline may already have the column.
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS tenant_id bigint;
+
+UPDATE refresh_tokens
+SET tenant_id = users.tenant_id
+FROM users
+WHERE users.id = refresh_tokens.user_id AND refresh_tokens.tenant_id IS NULL;
+
+-- Tokens of deleted users cannot be used any more.
+DELETE FROM refresh_tokens WHERE tenant_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN tenant_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_tenant_id ON refresh_tokens (tenant_id);
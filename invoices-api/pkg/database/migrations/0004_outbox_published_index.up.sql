@@ -0,0 +1,3 @@
+-- migrate:no-transaction
+-- Every instance polls for newly published events to feed its live streams.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_outbox_events_published ON outbox_events (published_at, id) WHERE status = 'published';
@@ -0,0 +1,3 @@
+-- Invoice listings send the time the organization's invoices last changed as
+-- Last-Modified. The time of the newest invoice would not reflect deletes.
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS invoices_changed_at timestamptz NOT NULL DEFAULT now();
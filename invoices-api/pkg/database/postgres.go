@@ -1,68 +1,148 @@
 package database
 
 import (
+	"cmp"
 	"fmt"
-	"invoices-api/internal/models"
-	"log"
+	"log/slog"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
+// ReplicaResolver names the dbresolver configuration of the read replicas.
+const ReplicaResolver = "replicas"
+
 type DatabaseConfig struct {
 	Host     string
 	User     string
 	Password string
 	DBName   string
 	Port     string
+
+	// SSLMode is a libpq sslmode such as disable, require or verify-full.
+	// SSLRootCert is the CA certificate to verify the server with;
+	// SSLCert and SSLKey are a client certificate.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+	// StatementTimeout aborts statements that run longer; zero disables it.
+	StatementTimeout time.Duration
+
+	// Replicas are "host" or "host:port" addresses of read replicas. They
+	// share the credentials, TLS and pool settings of the primary.
+	Replicas []string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	LogLevel        logger.LogLevel
+	// SlowQuery is the duration above which queries are logged as slow.
+	SlowQuery time.Duration
+}
+
+// ParseLogLevel maps silent, error, warn and info to GORM log levels.
+func ParseLogLevel(name string) (logger.LogLevel, error) {
+	switch name {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
 }
 
-func NewDatabaseConfig() *DatabaseConfig {
-	return &DatabaseConfig{
-		Host:     os.Getenv("DB_HOST"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		Port:     os.Getenv("DB_PORT"),
+// DSN returns the connection string for host and port.
+func (c *DatabaseConfig) DSN(host, port string) string {
+	params := [][2]string{
+		{"host", host},
+		{"port", port},
+		{"user", c.User},
+		{"password", c.Password},
+		{"dbname", c.DBName},
+		{"sslmode", cmp.Or(c.SSLMode, "disable")},
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+	}
+	if c.StatementTimeout > 0 {
+		params = append(params, [2]string{"statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)})
+	}
+
+	var parts []string
+	for _, param := range params {
+		if param[1] == "" {
+			continue
+		}
+		parts = append(parts, param[0]+"="+quoteDSNValue(param[1]))
 	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue quotes values so that passwords may contain spaces, quotes
+// and backslashes.
+func quoteDSNValue(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + value + "'"
+}
+
+// OnReplica returns a handle whose queries go to a read replica, or to the
+// primary when no replicas are configured. Reads through it may lag behind
+// recent writes, so it is only meant for listings and reports.
+func OnReplica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(ReplicaResolver)).Session(&gorm.Session{})
 }
 
 func ConnectDB(config *DatabaseConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		config.Host, config.User, config.Password, config.DBName, config.Port,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		// GORM sorgu loglarını açalım
-		Logger: logger.Default.LogMode(logger.Info),
+	logLevel := config.LogLevel
+	if logLevel == 0 {
+		logLevel = logger.Info
+	}
+
+	db, err := gorm.Open(postgres.Open(config.DSN(config.Host, config.Port)), &gorm.Config{
+		Logger: NewLogger(logLevel, config.SlowQuery),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	var replicas []gorm.Dialector
+	for _, address := range config.Replicas {
+		host, port := address, config.Port
+		if h, p, err := net.SplitHostPort(address); err == nil {
+			host, port = h, p
+		}
+		replicas = append(replicas, postgres.Open(config.DSN(host, port)))
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	// Şemayı doğrulayarak migrasyon yap
-	err = db.AutoMigrate(&models.Invoice{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	// Queries only use the replicas when they ask for them with OnReplica;
+	// everything else, including all writes, stays on the primary. Without
+	// replicas the resolver falls back to the primary.
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: replicas}, ReplicaResolver)
+	resolver.
+		SetMaxIdleConns(valueOr(config.MaxIdleConns, 10)).
+		SetMaxOpenConns(valueOr(config.MaxOpenConns, 100)).
+		SetConnMaxLifetime(valueOr(config.ConnMaxLifetime, time.Hour)).
+		SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if err := db.Use(resolver); err != nil {
+		return nil, fmt.Errorf("failed to connect to read replicas: %w", err)
 	}
 
-	log.Println("Database migrated successfully")
-
-	if err := SeedData(db); err != nil {
-		log.Printf("Warning: Failed to seed database: %v", err)
+	if err := db.Use(tracingPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register query tracing: %w", err)
 	}
 
 	return db, nil
@@ -75,19 +155,25 @@ func ConnectDBWithRetry(config *DatabaseConfig, maxRetries int) *gorm.DB {
 	for i := 0; i < maxRetries; i++ {
 		db, err = ConnectDB(config)
 		if err == nil {
-			log.Println("Successfully connected to database")
+			slog.Info("Connected to database", "host", config.Host, "replicas", len(config.Replicas))
 			return db
 		}
 
-		log.Printf("Failed to connect to database (Attempt %d/%d): %v", i+1, maxRetries, err)
-
 		if i < maxRetries-1 {
 			retryDelay := time.Duration(i+1) * 5 * time.Second
-			log.Printf("Retrying in %v...", retryDelay)
+			slog.Warn("Failed to connect to database", "attempt", i+1, "max_attempts", maxRetries, "retry_in", retryDelay, "error", err)
 			time.Sleep(retryDelay)
 		}
 	}
 
-	log.Fatalf("Could not connect to database after %d attempts", maxRetries)
+	slog.Error("Could not connect to database", "attempts", maxRetries, "error", err)
+	os.Exit(1)
 	return nil
 }
+
+func valueOr[T int | time.Duration](value, defaultValue T) T {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -1,86 +1,262 @@
 package database
 
 import (
+	"embed"
+	"encoding/json"
 	"fmt"
 	"invoices-api/internal/models"
-	"log"
+	"invoices-api/pkg/validator"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func SeedData(db *gorm.DB) error {
-	var count int64
-	db.Model(&models.Invoice{}).Count(&count)
-	if count > 0 {
-		log.Println("Database already seeded, skipping...")
+//go:embed fixtures/*.yaml
+var fixtureFiles embed.FS
+
+// seedBatchSize is the number of invoices written per INSERT statement.
+const seedBatchSize = 500
+
+// Fixture is the content of a fixture file. Organization is a slug; the
+// organization is created when it does not exist.
+type Fixture struct {
+	Organization     string           `json:"organization" yaml:"organization"`
+	OrganizationName string           `json:"organization_name" yaml:"organization_name"`
+	Invoices         []FixtureInvoice `json:"invoices" yaml:"invoices"`
+}
+
+// FixtureInvoice holds dates as strings, either "2006-01-02" or RFC 3339, so
+// that YAML and JSON fixtures are read the same way.
+type FixtureInvoice struct {
+	InvoiceNumber int     `json:"invoice_number" yaml:"invoice_number"`
+	ServiceName   string  `json:"service_name" yaml:"service_name"`
+	Date          string  `json:"date" yaml:"date"`
+	Amount        float64 `json:"amount" yaml:"amount"`
+	Status        string  `json:"status" yaml:"status"`
+	CustomerEmail string  `json:"customer_email" yaml:"customer_email"`
+	DueDate       string  `json:"due_date" yaml:"due_date"`
+}
+
+// SeedProfiles lists the fixture profiles embedded in the binary.
+func SeedProfiles() []string {
+	entries, err := fs.ReadDir(fixtureFiles, "fixtures")
+	if err != nil {
+		return nil
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		profiles = append(profiles, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	return profiles
+}
+
+// LoadProfile reads the embedded fixture of the given profile.
+func LoadProfile(profile string) (*Fixture, error) {
+	if !slices.Contains(SeedProfiles(), profile) {
+		return nil, fmt.Errorf("unknown seed profile %q, expected one of: %s", profile, strings.Join(SeedProfiles(), ", "))
+	}
+
+	data, err := fixtureFiles.ReadFile(path.Join("fixtures", profile+".yaml"))
+	if err != nil {
+		return nil, err
+	}
+	return parseFixture(data, false)
+}
+
+// LoadFixtureFile reads a fixture from a .yaml, .yml or .json file.
+func LoadFixtureFile(name string) (*Fixture, error) {
+	var isJSON bool
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		isJSON = true
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("%s: fixture files must end in .yaml, .yml or .json", name)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, err := parseFixture(data, isJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return fixture, nil
+}
+
+func parseFixture(data []byte, isJSON bool) (*Fixture, error) {
+	var fixture Fixture
+	var err error
+	if isJSON {
+		err = json.Unmarshal(data, &fixture)
+	} else {
+		err = yaml.Unmarshal(data, &fixture)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture: %w", err)
+	}
+
+	if fixture.Organization == "" {
+		fixture.Organization = models.DefaultOrganizationSlug
+	}
+	return &fixture, nil
+}
+
+// Models converts and validates the fixture's invoices. Invoice numbers have
+// to be unique within a fixture, as they identify the rows on later runs.
+func (f *Fixture) Models() ([]models.Invoice, error) {
+	v := validator.NewInvoiceValidator()
+	seen := map[int]bool{}
+
+	invoices := make([]models.Invoice, 0, len(f.Invoices))
+	for i, item := range f.Invoices {
+		invoice, err := item.invoice()
+		if err != nil {
+			return nil, fmt.Errorf("invoice %d: %w", i+1, err)
+		}
+
+		if errs := v.ValidateInvoice(&invoice); len(errs) > 0 {
+			return nil, fmt.Errorf("invoice %d: %s: %s", i+1, errs[0].Field, errs[0].Message)
+		}
+		if seen[invoice.InvoiceNumber] {
+			return nil, fmt.Errorf("invoice %d: duplicate invoice_number %d", i+1, invoice.InvoiceNumber)
+		}
+		seen[invoice.InvoiceNumber] = true
+
+		invoices = append(invoices, invoice)
+	}
+	return invoices, nil
+}
+
+func (item FixtureInvoice) invoice() (models.Invoice, error) {
+	invoice := models.Invoice{
+		InvoiceNumber: item.InvoiceNumber,
+		ServiceName:   item.ServiceName,
+		Amount:        item.Amount,
+		Status:        item.Status,
+		CustomerEmail: item.CustomerEmail,
+	}
+
+	if item.Date != "" {
+		date, err := parseFixtureDate(item.Date)
+		if err != nil {
+			return invoice, fmt.Errorf("date: %w", err)
+		}
+		invoice.Date = date
+	}
+
+	if item.DueDate != "" {
+		dueDate, err := parseFixtureDate(item.DueDate)
+		if err != nil {
+			return invoice, fmt.Errorf("due_date: %w", err)
+		}
+		invoice.DueDate = &dueDate
+	}
+
+	return invoice, nil
+}
+
+func parseFixtureDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC 3339", value)
+	}
+	return date.UTC(), nil
+}
+
+// Seed writes the fixture's invoices into its organization.
+func Seed(db *gorm.DB, fixture *Fixture) (int, error) {
+	invoices, err := fixture.Models()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := SeedInvoices(db, fixture.Organization, fixture.OrganizationName, invoices); err != nil {
+		return 0, err
+	}
+	return len(invoices), nil
+}
+
+// SeedInvoices upserts invoices into the organization with the given slug,
+// creating it under name if it does not exist.
+func SeedInvoices(db *gorm.DB, slug, name string, invoices []models.Invoice) error {
+	org, err := seedOrganization(db, slug, name)
+	if err != nil {
+		return err
+	}
+
+	if err := UpsertInvoices(db, org.ID, invoices); err != nil {
+		return err
+	}
+
+	slog.Info("Seeded invoices", "count", len(invoices), "organization", org.Slug)
+	return nil
+}
+
+// SeedProfile seeds the embedded fixture of the given profile.
+func SeedProfile(db *gorm.DB, profile string) (int, error) {
+	fixture, err := LoadProfile(profile)
+	if err != nil {
+		return 0, err
+	}
+	return Seed(db, fixture)
+}
+
+// UpsertInvoices inserts invoices into the tenant, or updates the ones whose
+// invoice number already exists there, so seeding twice does not create
+// duplicates. It writes directly and does not publish events.
+func UpsertInvoices(db *gorm.DB, tenantID uint, invoices []models.Invoice) error {
+	if len(invoices) == 0 {
 		return nil
 	}
 
-	invoices := []models.Invoice{
-		{
-			ServiceName:   "DMP Service",
-			InvoiceNumber: 1001,
-			Date:          time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
-			Amount:        1500.50,
-			Status:        "Pending",
-		},
-		{
-			ServiceName:   "SSP Service",
-			InvoiceNumber: 1002,
-			Date:          time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC),
-			Amount:        2500.75,
-			Status:        "Paid",
-		},
-		{
-			ServiceName:   "DMP Service",
-			InvoiceNumber: 1003,
-			Date:          time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
-			Amount:        750.25,
-			Status:        "Unpaid",
-		},
-		{
-			ServiceName:   "DDP Service",
-			InvoiceNumber: 1004,
-			Date:          time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
-			Amount:        1500.50,
-			Status:        "Pending",
-		},
-		{
-			ServiceName:   "SSP Service",
-			InvoiceNumber: 1005,
-			Date:          time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC),
-			Amount:        2500.75,
-			Status:        "Paid",
-		},
-		{
-			ServiceName:   "DMP Service",
-			InvoiceNumber: 1006,
-			Date:          time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
-			Amount:        750.25,
-			Status:        "Unpaid",
-		},
-		{
-			ServiceName:   "SSP Service",
-			InvoiceNumber: 1007,
-			Date:          time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
-			Amount:        750.25,
-			Status:        "Unpaid",
-		},
-		{
-			ServiceName:   "DSP Service",
-			InvoiceNumber: 1008,
-			Date:          time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
-			Amount:        750.25,
-			Status:        "Unpaid",
-		},
-	}
-
-	log.Println("Seeding database...")
-	if err := db.Create(&invoices).Error; err != nil {
-		return fmt.Errorf("failed to seed database: %w", err)
-	}
-
-	log.Printf("Successfully seeded %d invoices", len(invoices))
+	for i := range invoices {
+		invoices[i].ID = 0
+		invoices[i].TenantID = tenantID
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "invoice_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"service_name", "date", "amount", "status", "customer_email", "due_date", "updated_at",
+			}),
+		}).CreateInBatches(&invoices, seedBatchSize).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed invoices: %w", err)
+	}
 	return nil
 }
+
+func seedOrganization(db *gorm.DB, slug, name string) (*models.Organization, error) {
+	if slug == models.DefaultOrganizationSlug {
+		return DefaultOrganization(db)
+	}
+	if name == "" {
+		name = slug
+	}
+
+	org := models.Organization{Slug: slug}
+	if err := db.Where(models.Organization{Slug: slug}).
+		Attrs(models.Organization{Name: name}).
+		FirstOrCreate(&org).Error; err != nil {
+		return nil, fmt.Errorf("failed to create organization %q: %w", slug, err)
+	}
+	return &org, nil
+}
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"fmt"
+	"invoices-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultOrganization returns the default organization, creating it if
+// needed.
+func DefaultOrganization(db *gorm.DB) (*models.Organization, error) {
+	org := models.Organization{Slug: models.DefaultOrganizationSlug}
+	if err := db.Where(models.Organization{Slug: org.Slug}).
+		Attrs(models.Organization{Name: "Default"}).
+		FirstOrCreate(&org).Error; err != nil {
+		return nil, fmt.Errorf("failed to create default organization: %w", err)
+	}
+	return &org, nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracingSpanKey = "invoices-api:span"
+
+var tracer = otel.Tracer("invoices-api/pkg/database")
+
+// tracingPlugin records a client span for every query GORM runs, as a child
+// of the span in the statement's context. The SQL is recorded with
+// placeholders, never with its arguments.
+type tracingPlugin struct{}
+
+type tracedQuery struct {
+	span      trace.Span
+	operation string
+}
+
+func (tracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (p tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.start("INSERT")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.end),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.start("SELECT")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.end),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.start("UPDATE")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.end),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.start("DELETE")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.end),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.start("SELECT")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.end),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.start("RAW")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.end),
+	)
+}
+
+func (tracingPlugin) start(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			// Queries outside of a traced request, such as those of the
+			// background workers, would each start a trace of their own.
+			return
+		}
+
+		_, span := tracer.Start(ctx, operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.operation.name", operation),
+			),
+		)
+		tx.InstanceSet(tracingSpanKey, tracedQuery{span: span, operation: operation})
+	}
+}
+
+func (tracingPlugin) end(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	query := value.(tracedQuery)
+	defer query.span.End()
+
+	if table := tx.Statement.Table; table != "" {
+		query.span.SetName(query.operation + " " + table)
+		query.span.SetAttributes(attribute.String("db.collection.name", table))
+	}
+	query.span.SetAttributes(
+		attribute.String("db.query.text", tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+
+	if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		query.span.RecordError(err)
+		query.span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFields are matched against lower-cased field names with dashes and
+// underscores removed, so that "refresh_token" and "refreshToken" both match.
+var sensitiveFields = []string{
+	"password", "secret", "token", "apikey", "authorization", "cookie", "credential",
+}
+
+// RedactBody prepares a request body for logging. Values of JSON fields
+// whose names suggest credentials are replaced at any depth, and so are
+// those of form fields. Any other body, or one that does not parse, is only
+// described by its size and content type, since there is no telling what
+// it contains.
+func RedactBody(contentType string, body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value any
+		if err := json.Unmarshal(body, &value); err == nil {
+			return redact(value)
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			return redactForm(form)
+		}
+	}
+
+	return map[string]any{
+		"size":         len(body),
+		"content_type": contentType,
+	}
+}
+
+// redactForm returns the fields of form with a single value as strings and
+// the others as lists.
+func redactForm(form url.Values) map[string]any {
+	fields := make(map[string]any, len(form))
+	for key, values := range form {
+		switch {
+		case isSensitive(key):
+			fields[key] = redacted
+		case len(values) == 1:
+			fields[key] = values[0]
+		default:
+			fields[key] = values
+		}
+	}
+	return fields
+}
+
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"email":"a@example.com","password":"hunter2","nested":[{"refreshToken":"abc"}]}`,
+			want:        `{"email":"a@example.com","nested":[{"refreshToken":"[REDACTED]"}],"password":"[REDACTED]"}`,
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=password&username=a&password=hunter2&client_secret=s&scope=a&scope=b",
+			want:        `{"client_secret":"[REDACTED]","grant_type":"password","password":"[REDACTED]","scope":["a","b"],"username":"a"}`,
+		},
+		{
+			name:        "text",
+			contentType: "text/plain",
+			body:        "password=hunter2",
+			want:        `{"content_type":"text/plain","size":16}`,
+		},
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        `{"password":"hunter2"`,
+			want:        `{"content_type":"application/json","size":21}`,
+		},
+		{
+			name:        "no content type",
+			contentType: "",
+			body:        `{"password":"hunter2"}`,
+			want:        `{"content_type":"","size":22}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(RedactBody(tt.contentType, []byte(tt.body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := RedactBody("application/json", nil); got != nil {
+		t.Errorf("got %v for an empty body, want nil", got)
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	TokenIssuer = "invoices-api"
+
+	// APIKeyPrefix starts every API key so that keys can be told apart from
+	// JWTs in the Authorization header.
+	APIKeyPrefix = "inv_"
+	HeaderAPIKey = "X-API-Key"
+
+	principalLocalsKey = "principal"
+)
+
+type principalContextKey struct{}
+
+// Principal is the authenticated caller of a request. It is stored in the
+// fiber locals for handlers and in the user context so that it reaches the
+// repository layer through context.Context.
+//
+// Requests authenticated with an API key carry the key's ID and scopes in
+// addition to the role of the user who created the key.
+type Principal struct {
+	UserID   uint         `json:"user_id"`
+	Email    string       `json:"email"`
+	Role     string       `json:"role"`
+	TenantID uint         `json:"tenant_id"`
+	APIKeyID uint         `json:"api_key_id,omitempty"`
+	Scopes   []Permission `json:"scopes,omitempty"`
+}
+
+type AccessClaims struct {
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	TenantID uint   `json:"tenant_id"`
+	jwt.RegisteredClaims
+}
+
+// APIKeyResolver looks up the principal an API key belongs to. It returns an
+// error when the key is unknown, revoked or expired.
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+type AuthConfig struct {
+	Secret []byte
+	// APIKeys is optional; without it only bearer JWTs are accepted.
+	APIKeys APIKeyResolver
+}
+
+// Authenticate accepts a JWT access token or an API key, either as a bearer
+// token in the Authorization header or in the X-API-Key header.
+func Authenticate(config AuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := c.Get(HeaderAPIKey); key != "" {
+			return authenticateAPIKey(c, config, key)
+		}
+
+		token, ok := bearerToken(c)
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="`+TokenIssuer+`"`)
+			return NewUnauthorizedError("Missing bearer token")
+		}
+
+		if strings.HasPrefix(token, APIKeyPrefix) {
+			return authenticateAPIKey(c, config, token)
+		}
+
+		principal, err := ParseAccessToken(token, config.Secret)
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="`+TokenIssuer+`", error="invalid_token"`)
+			return NewUnauthorizedError("Invalid or expired token")
+		}
+
+		SetPrincipal(c, principal)
+		return c.Next()
+	}
+}
+
+func authenticateAPIKey(c *fiber.Ctx, config AuthConfig, key string) error {
+	if config.APIKeys == nil {
+		return NewUnauthorizedError("API keys are not accepted")
+	}
+
+	principal, err := config.APIKeys.ResolveAPIKey(c.UserContext(), key)
+	if err != nil {
+		if e, ok := err.(*ErrorResponse); ok && e.Code != fiber.StatusUnauthorized {
+			return e
+		}
+		return NewUnauthorizedError("Invalid, revoked or expired API key")
+	}
+
+	SetPrincipal(c, principal)
+	return c.Next()
+}
+
+func ParseAccessToken(token string, secret []byte) (*Principal, error) {
+	claims := new(AccessClaims)
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return nil, errors.New("invalid token subject")
+	}
+
+	if claims.TenantID == 0 {
+		return nil, errors.New("token has no tenant")
+	}
+
+	return &Principal{
+		UserID:   uint(userID),
+		Email:    claims.Email,
+		Role:     claims.Role,
+		TenantID: claims.TenantID,
+	}, nil
+}
+
+func SetPrincipal(c *fiber.Ctx, principal *Principal) {
+	c.Locals(principalLocalsKey, principal)
+	c.SetUserContext(context.WithValue(c.UserContext(), principalContextKey{}, principal))
+}
+
+func GetPrincipal(c *fiber.Ctx) *Principal {
+	principal, _ := c.Locals(principalLocalsKey).(*Principal)
+	return principal
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// varyHeaders select the principal and organization, so responses differ
+// between them even for the same URL.
+var varyHeaders = []string{fiber.HeaderAuthorization, HeaderAPIKey, HeaderTenantID}
+
+// CacheControl sets the Cache-Control header of successful and 304 GET
+// responses. An empty value leaves it unset.
+func CacheControl(value string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if value != "" && (status == fiber.StatusOK || status == fiber.StatusNotModified) {
+			c.Set(fiber.HeaderCacheControl, value)
+			c.Vary(varyHeaders...)
+		}
+		return nil
+	}
+}
+
+// WeakETag derives a weak entity tag from the parts, which together must
+// change whenever the representation does.
+func WeakETag(parts ...any) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(hash, "%v\x00", part)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// NotModified sets the ETag and Last-Modified headers of the response and
+// reports whether the request's If-None-Match or If-Modified-Since header
+// shows that the client already has this representation, in which case the
+// status is set to 304 and the handler should send no body. As in RFC 9110,
+// If-Modified-Since is ignored when If-None-Match is present. A zero
+// lastModified sends no Last-Modified and ignores If-Modified-Since.
+func NotModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+
+	fresh := false
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		fresh = etagMatches(noneMatch, etag)
+	} else if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !lastModified.IsZero() {
+		// HTTP dates have whole seconds, so the stored time is truncated
+		// before comparing.
+		if t, err := http.ParseTime(since); err == nil {
+			fresh = !lastModified.Truncate(time.Second).After(t)
+		}
+	}
+
+	if fresh {
+		c.Status(fiber.StatusNotModified)
+	}
+	return fresh
+}
+
+// etagMatches uses the weak comparison If-None-Match calls for.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,8 @@ type ErrorResponse struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Details interface{} `json:"details,omitempty"`
+	// TraceID identifies the request's trace when reporting a problem.
+	TraceID string `json:"trace_id,omitempty"`
 }
 
 func (e *ErrorResponse) Error() string {
@@ -32,6 +34,7 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 		}
 	}
 
+	response.TraceID = TraceID(c)
 	return c.Status(response.Code).JSON(response)
 }
 
@@ -50,10 +53,22 @@ func NewBadRequestError(message string, details ...interface{}) *ErrorResponse {
 	return NewError(fiber.StatusBadRequest, message, details...)
 }
 
+func NewUnauthorizedError(message string, details ...interface{}) *ErrorResponse {
+	return NewError(fiber.StatusUnauthorized, message, details...)
+}
+
+func NewForbiddenError(message string, details ...interface{}) *ErrorResponse {
+	return NewError(fiber.StatusForbidden, message, details...)
+}
+
 func NewNotFoundError(message string, details ...interface{}) *ErrorResponse {
 	return NewError(fiber.StatusNotFound, message, details...)
 }
 
+func NewTooManyRequestsError(message string, details ...interface{}) *ErrorResponse {
+	return NewError(fiber.StatusTooManyRequests, message, details...)
+}
+
 func NewInternalError(message string, details ...interface{}) *ErrorResponse {
 	return NewError(fiber.StatusInternalServerError, message, details...)
 }
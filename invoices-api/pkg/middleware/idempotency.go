@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyReleaseTimeout = 5 * time.Second
+)
+
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyEntry is a previously seen request. Response is only set once
+// the request has completed.
+type IdempotencyEntry struct {
+	Fingerprint string
+	Completed   bool
+	Response    IdempotentResponse
+}
+
+// IdempotencyStore persists idempotency keys. Keys are scoped to the tenant
+// in the context.
+type IdempotencyStore interface {
+	// Reserve claims a key for a new request. When the key is already taken
+	// it returns the existing entry and false.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyEntry, bool, error)
+	Complete(ctx context.Context, key string, response IdempotentResponse) error
+	// Release forgets a reserved key so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	TTL   time.Duration
+}
+
+// Idempotency replays the stored response when a POST, PUT, PATCH or DELETE
+// request is retried with the same Idempotency-Key. Keys are scoped to the
+// calling client; reusing one with a different request is rejected with 422.
+// Only 2xx responses are stored, so a request that failed, whether with an
+// error or a response written by the handler, can be retried with the same
+// key. It must run after Authenticate and Tenant.
+func Idempotency(config IdempotencyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return c.Next()
+		}
+
+		header := c.Get(HeaderIdempotencyKey)
+		if header == "" {
+			return c.Next()
+		}
+		if len(header) > maxIdempotencyKeyLength {
+			return NewBadRequestError("Idempotency-Key must be at most 255 characters")
+		}
+
+		key := ClientKey(c) + ":" + header
+		fingerprint := requestFingerprint(c)
+
+		entry, reserved, err := config.Store.Reserve(c.UserContext(), key, fingerprint, config.TTL)
+		if err != nil {
+			return err
+		}
+
+		if !reserved {
+			if entry.Fingerprint != fingerprint {
+				return NewError(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			}
+			if !entry.Completed {
+				return NewError(fiber.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			}
+
+			c.Set(HeaderIdempotentReplayed, "true")
+			if entry.Response.ContentType != "" {
+				c.Set(fiber.HeaderContentType, entry.Response.ContentType)
+			}
+			return c.Status(entry.Response.StatusCode).Send(entry.Response.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			releaseIdempotencyKey(c, config.Store, key)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+			releaseIdempotencyKey(c, config.Store, key)
+			return nil
+		}
+
+		response := IdempotentResponse{
+			StatusCode:  status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}
+		if err := config.Store.Complete(c.UserContext(), key, response); err != nil {
+			slog.ErrorContext(c.UserContext(), "Failed to store idempotent response", "error", err)
+		}
+
+		return nil
+	}
+}
+
+// releaseIdempotencyKey uses a fresh context since the request context may
+// already have timed out.
+func releaseIdempotencyKey(c *fiber.Ctx, store IdempotencyStore, key string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.UserContext()), idempotencyReleaseTimeout)
+	defer cancel()
+
+	if err := store.Release(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+	}
+}
+
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
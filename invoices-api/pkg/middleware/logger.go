@@ -1,75 +1,119 @@
-// pkg/middleware/logger.go
 package middleware
 
 import (
-	"encoding/json"
-	"log"
-	"os"
+	"invoices-api/pkg/logging"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-var logger = log.New(os.Stdout, "\n🔍 [API] ", log.LstdFlags)
+const (
+	HeaderRequestID = "X-Request-ID"
 
-type RequestLog struct {
-	ID           string      `json:"request_id"`
-	Timestamp    time.Time   `json:"timestamp"`
-	Method       string      `json:"method"`
-	Path         string      `json:"path"`
-	Status       int         `json:"status"`
-	Duration     float64     `json:"duration_ms"`
-	IP           string      `json:"ip"`
-	UserAgent    string      `json:"user_agent"`
-	RequestBody  interface{} `json:"request_body,omitempty"`
-	ResponseBody interface{} `json:"response_body,omitempty"`
-	QueryParams  string      `json:"query_params,omitempty"`
-	ErrorMessage string      `json:"error,omitempty"`
-}
+	requestIDLocalsKey = "request_id"
+	maxRequestIDLength = 128
+)
 
-func RequestLogger() fiber.Handler {
+// RequestID assigns every request an ID, echoed in the X-Request-ID response
+// header and stored in the user context for logging. An ID sent by a proxy
+// in the same header is kept when it is short and printable. It must run
+// first so that everything after it can log the ID.
+func RequestID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		start := time.Now()
-		requestID := uuid.New().String()
-		c.Set("X-Request-ID", requestID)
+		id := c.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
 
-		// Request body log
-		var requestBody interface{}
-		if len(c.Body()) > 0 {
-			if err := json.Unmarshal(c.Body(), &requestBody); err != nil {
-				requestBody = string(c.Body())
-			}
+		c.Set(HeaderRequestID, id)
+		c.Locals(requestIDLocalsKey, id)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
 		}
+	}
+	return true
+}
 
-		// Execute handler
-		err := c.Next()
+type RequestLoggerConfig struct {
+	// LogBodies adds request bodies, with credentials redacted.
+	LogBodies bool
+}
 
-		// Response duration
-		duration := time.Since(start).Milliseconds()
+// RequestLogger writes one record per request once it has completed: server
+// errors at the error level, client errors at the warn level and everything
+// else at the info level. It must run after RequestID and before the error
+// handler turns errors into responses, which fiber does after the whole
+// chain returns, so the status of a failed request is taken from its error.
+func RequestLogger(config RequestLoggerConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
 
-		// Log entry
-		logEntry := RequestLog{
-			ID:          requestID,
-			Timestamp:   start,
-			Method:      c.Method(),
-			Path:        c.Path(),
-			Status:      c.Response().StatusCode(),
-			Duration:    float64(duration),
-			IP:          c.IP(),
-			UserAgent:   c.Get("User-Agent"),
-			QueryParams: string(c.Request().URI().QueryString()),
-			RequestBody: requestBody,
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
 		}
 
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", logging.Milliseconds(time.Since(start))),
+			slog.String("ip", c.IP()),
+			slog.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+		}
+		if query := c.Request().URI().QueryString(); len(query) > 0 {
+			attrs = append(attrs, slog.String("query", string(query)))
+		}
+		if principal := GetPrincipal(c); principal != nil {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(principal.UserID)))
+		}
+		if tenantID, ok := TenantFromContext(c.UserContext()); ok {
+			attrs = append(attrs, slog.Uint64("tenant_id", uint64(tenantID)))
+		}
+		if config.LogBodies {
+			if body := logging.RedactBody(c.Get(fiber.HeaderContentType), c.Body()); body != nil {
+				attrs = append(attrs, slog.Any("request_body", body))
+			}
+		}
 		if err != nil {
-			logEntry.ErrorMessage = err.Error()
+			attrs = append(attrs, slog.String("error", err.Error()))
 		}
 
-		// JSON olarak tek bir satırda yazdır
-		entryJSON, _ := json.Marshal(logEntry)
-		logger.Println(string(entryJSON))
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
 
+		slog.LogAttrs(c.UserContext(), level, "request", attrs...)
 		return err
 	}
 }
+
+// errorStatus is the status ErrorHandler will answer err with.
+func errorStatus(err error) int {
+	switch e := err.(type) {
+	case *ErrorResponse:
+		return e.Code
+	case *fiber.Error:
+		return e.Code
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -14,34 +16,80 @@ var (
 	httpRequestsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
+			Help: "Total number of HTTP requests by method, route and status code",
 		},
-		[]string{"method", "path", "status"},
+		[]string{"method", "route", "status"},
 	)
 
 	httpRequestDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_request_duration_seconds",
 			Help:    "HTTP request duration in seconds",
-			Buckets: []float64{0.1, 0.3, 0.5, 0.7, 1, 3, 5, 7, 10},
+			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"method", "path"},
+		[]string{"method", "route"},
+	)
+
+	httpRequestsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served",
+		},
+	)
+
+	// sizeBuckets range from 100 bytes to 10 MB.
+	sizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+	httpRequestSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "HTTP request body size in bytes",
+			Buckets: sizeBuckets,
+		},
+		[]string{"method", "route"},
+	)
+
+	httpResponseSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size in bytes, without streamed responses",
+			Buckets: sizeBuckets,
+		},
+		[]string{"method", "route"},
 	)
 )
 
+// PrometheusMiddleware records the requests by route template, such as
+// /api/v1/invoices/:id, rather than by path, which would create a series per
+// invoice; requests that matched no route share one label, so that scans of
+// random paths cannot create new series. Like RequestLogger it runs before
+// the error handler, so the status of a failed request is taken from its
+// error, and the size of error responses, which are written after the chain
+// returns, is not recorded.
 func PrometheusMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
-		method := c.Method()
-		path := c.Path()
+		// fiber's strings are only valid during the request, while the
+		// label values are kept.
+		method := utils.CopyString(c.Method())
+
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
 
 		err := c.Next()
 
 		status := c.Response().StatusCode()
-		duration := time.Since(start).Seconds()
+		if err != nil {
+			status = errorStatus(err)
+		}
+		route := routeLabel(c, err)
 
-		httpRequestsTotal.WithLabelValues(method, path, string(status)).Inc()
-		httpRequestDuration.WithLabelValues(method, path).Observe(duration)
+		httpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		httpRequestSize.WithLabelValues(method, route).Observe(float64(len(c.Request().Body())))
+		if err == nil && !c.Response().IsBodyStream() {
+			httpResponseSize.WithLabelValues(method, route).Observe(float64(len(c.Response().Body())))
+		}
 
 		return err
 	}
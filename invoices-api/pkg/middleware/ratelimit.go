@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limit is a token bucket: Burst requests can be made at once, and the bucket
+// refills at Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// ParseLimit reads limits of the form "<count>/<unit>[:<burst>]" where unit
+// is s, m or h, e.g. "100/m" or "5/s:20". The burst defaults to the count.
+// "off" or an empty string disables the limit.
+func ParseLimit(spec string) (Limit, error) {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	if spec == "" || spec == "off" {
+		return Limit{}, nil
+	}
+
+	rateSpec, burstSpec, hasBurst := strings.Cut(spec, ":")
+	countSpec, unit, ok := strings.Cut(rateSpec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", spec)
+	}
+
+	count, err := strconv.Atoi(countSpec)
+	if err != nil || count < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit count in %q", spec)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q", spec)
+	}
+
+	burst := count
+	if hasBurst {
+		burst, err = strconv.Atoi(burstSpec)
+		if err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid rate limit burst in %q", spec)
+		}
+	}
+
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: burst}, nil
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, and Reset
+	// how long until the bucket is full again.
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// RateLimitStore holds the buckets. The in-memory store only limits a single
+// instance; a shared implementation can be plugged in to limit across
+// replicas.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+type RateLimitConfig struct {
+	// Name separates the buckets of different route groups.
+	Name  string
+	Limit Limit
+	Store RateLimitStore
+	// KeyFunc identifies the client. It defaults to ClientKey.
+	KeyFunc func(c *fiber.Ctx) string
+}
+
+func RateLimit(config RateLimitConfig) fiber.Handler {
+	if !config.Limit.Enabled() {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	if config.KeyFunc == nil {
+		config.KeyFunc = ClientKey
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := config.Name + ":" + config.KeyFunc(c)
+
+		result, err := config.Store.Take(c.UserContext(), key, config.Limit, time.Now())
+		if err != nil {
+			// A broken store must not take the API down with it.
+			slog.ErrorContext(c.UserContext(), "Rate limit store failed", "error", err)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(config.Limit.Burst))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return NewTooManyRequestsError("Rate limit exceeded", fiber.Map{
+				"retry_after": retryAfter,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// ClientKey identifies the caller by API key, then user, then IP address.
+func ClientKey(c *fiber.Ctx) string {
+	if principal := GetPrincipal(c); principal != nil {
+		if principal.APIKeyID != 0 {
+			return "key:" + strconv.FormatUint(uint64(principal.APIKeyID), 10)
+		}
+		return "user:" + strconv.FormatUint(uint64(principal.UserID), 10)
+	}
+	return "ip:" + c.IP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+const memoryStoreSweepInterval = time.Minute
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket will be full again and can be forgotten.
+	full time.Time
+}
+
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (s *memoryRateLimitStore) Take(_ context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: burst, last: now}
+		s.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.last).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(burst, bucket.tokens+elapsed*limit.Rate)
+		bucket.last = now
+	}
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - bucket.tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(bucket.tokens)
+	result.Reset = secondsDuration((burst - bucket.tokens) / limit.Rate)
+	bucket.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep drops buckets that have refilled, since a missing bucket behaves
+// exactly like a full one.
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memoryStoreSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Permission string
+
+const (
+	PermInvoicesRead     Permission = "invoices:read"
+	PermInvoicesWrite    Permission = "invoices:write"
+	PermInvoicesDelete   Permission = "invoices:delete"
+	PermInvoicesMarkPaid Permission = "invoices:mark_paid"
+	PermInvoicesImport   Permission = "invoices:import"
+	PermInvoicesExport   Permission = "invoices:export"
+	PermInvoicesSend     Permission = "invoices:send"
+	PermReportsRead      Permission = "reports:read"
+	PermAPIKeysManage    Permission = "apikeys:manage"
+	PermWebhooksManage   Permission = "webhooks:manage"
+	// PermTenantsSwitch allows acting on another organization through the
+	// X-Tenant-ID header, and PermOrganizationsManage creating organizations.
+	// Both are platform-wide and only granted to platform_admin by default.
+	PermTenantsSwitch       Permission = "tenants:switch"
+	PermOrganizationsManage Permission = "organizations:manage"
+
+	// PermAll grants every permission of an organization. Platform
+	// permissions are not included and must be granted by name.
+	PermAll Permission = "*"
+)
+
+const (
+	RoleViewer     = "viewer"
+	RoleEditor     = "editor"
+	RoleAccountant = "accountant"
+	RoleAdmin      = "admin"
+	// RolePlatformAdmin administers every organization. It is given to the
+	// bootstrap admin and cannot be assigned through the API.
+	RolePlatformAdmin = "platform_admin"
+)
+
+var knownPermissions = map[Permission]bool{
+	PermInvoicesRead:        true,
+	PermInvoicesWrite:       true,
+	PermInvoicesDelete:      true,
+	PermInvoicesMarkPaid:    true,
+	PermInvoicesImport:      true,
+	PermInvoicesExport:      true,
+	PermInvoicesSend:        true,
+	PermReportsRead:         true,
+	PermAPIKeysManage:       true,
+	PermWebhooksManage:      true,
+	PermTenantsSwitch:       true,
+	PermOrganizationsManage: true,
+	PermAll:                 true,
+}
+
+// platformPermissions reach beyond the organization of the principal, so the
+// wildcard does not grant them.
+var platformPermissions = map[Permission]bool{
+	PermTenantsSwitch:       true,
+	PermOrganizationsManage: true,
+}
+
+// IsValidScope reports whether a permission may be granted to an API key.
+// Keys cannot hold the wildcard, manage other keys or leave the organization
+// they were created in.
+func IsValidScope(permission Permission) bool {
+	switch permission {
+	case PermAll, PermAPIKeysManage, PermTenantsSwitch, PermOrganizationsManage:
+		return false
+	}
+	return knownPermissions[permission]
+}
+
+// DefaultPermissions is the role matrix used when no policy file is given.
+var DefaultPermissions = map[string][]Permission{
+	RoleViewer: {
+		PermInvoicesRead,
+		PermReportsRead,
+	},
+	RoleEditor: {
+		PermInvoicesRead,
+		PermInvoicesWrite,
+		PermInvoicesDelete,
+		PermInvoicesImport,
+		PermInvoicesExport,
+		PermInvoicesSend,
+		PermReportsRead,
+	},
+	RoleAccountant: {
+		PermInvoicesRead,
+		PermInvoicesWrite,
+		PermInvoicesMarkPaid,
+		PermInvoicesImport,
+		PermInvoicesExport,
+		PermInvoicesSend,
+		PermReportsRead,
+	},
+	RoleAdmin: {
+		PermAll,
+	},
+	RolePlatformAdmin: {
+		PermAll,
+		PermTenantsSwitch,
+		PermOrganizationsManage,
+	},
+}
+
+// Policy maps roles to the permissions they are granted.
+type Policy struct {
+	roles map[string]map[Permission]bool
+}
+
+func NewPolicy(matrix map[string][]Permission) (*Policy, error) {
+	if len(matrix) == 0 {
+		return nil, fmt.Errorf("policy defines no roles")
+	}
+
+	policy := &Policy{roles: make(map[string]map[Permission]bool, len(matrix))}
+	for role, permissions := range matrix {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" {
+			return nil, fmt.Errorf("policy contains an empty role name")
+		}
+
+		granted := make(map[Permission]bool, len(permissions))
+		for _, permission := range permissions {
+			if !knownPermissions[permission] {
+				return nil, fmt.Errorf("role %q has unknown permission %q", role, permission)
+			}
+			granted[permission] = true
+		}
+		policy.roles[role] = granted
+	}
+
+	return policy, nil
+}
+
+func DefaultPolicy() *Policy {
+	policy, _ := NewPolicy(DefaultPermissions)
+	return policy
+}
+
+// LoadPolicy reads a role matrix from a JSON file of the form
+// {"viewer": ["invoices:read"], "admin": ["*"]}. Platform permissions
+// must be listed next to "*". An empty path returns the
+// default policy.
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var matrix map[string][]Permission
+	if err := json.Unmarshal(data, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	return NewPolicy(matrix)
+}
+
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.roles[role]
+	return ok
+}
+
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.roles))
+	for role := range p.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Can reports whether the principal holds a permission. API key principals
+// need the permission both in the key's scopes and in the role of the user
+// who owns the key, so a key never outlives a demotion of its owner.
+func (p *Policy) Can(principal *Principal, permission Permission) bool {
+	if principal == nil {
+		return false
+	}
+
+	granted := p.roles[principal.Role]
+	if !granted[permission] && (!granted[PermAll] || platformPermissions[permission]) {
+		return false
+	}
+
+	if principal.APIKeyID != 0 {
+		return slices.Contains(principal.Scopes, permission)
+	}
+
+	return true
+}
+
+// Require returns a handler that rejects the request with 403 unless the
+// authenticated principal holds every given permission. It must run after
+// Authenticate.
+func (p *Policy) Require(permissions ...Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			return NewUnauthorizedError("Not authenticated")
+		}
+
+		for _, permission := range permissions {
+			if !p.Can(principal, permission) {
+				return NewForbiddenError("Insufficient permissions", fiber.Map{
+					"required": permission,
+					"role":     principal.Role,
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -2,7 +2,7 @@ package middleware
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"runtime/debug"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,18 +14,22 @@ func RecoverMiddleware() fiber.Handler {
 			if r := recover(); r != nil {
 				stack := debug.Stack()
 
-				log.Printf("🔥 Panic recovered: %v\n%s", r, string(stack))
+				slog.ErrorContext(c.UserContext(), "Panic recovered", "panic", fmt.Sprint(r), "stack", string(stack))
 
 				errMsg := "Internal server error"
 				if fiber.IsChild() { // Development ortamında
 					errMsg = fmt.Sprintf("Panic: %v", r)
 				}
 
-				c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				response := fiber.Map{
 					"success": false,
 					"message": errMsg,
 					"error":   "A panic occurred in the server",
-				})
+				}
+				if traceID := TraceID(c); traceID != "" {
+					response["trace_id"] = traceID
+				}
+				c.Status(fiber.StatusInternalServerError).JSON(response)
 			}
 		}()
 
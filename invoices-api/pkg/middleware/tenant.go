@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HeaderTenantID = "X-Tenant-ID"
+
+	tenantLocalsKey = "tenant_id"
+)
+
+type tenantContextKey struct{}
+
+// TenantLookup reports whether an organization exists.
+type TenantLookup interface {
+	TenantExists(ctx context.Context, id uint) (bool, error)
+}
+
+type TenantConfig struct {
+	Policy *Policy
+	Lookup TenantLookup
+}
+
+// Tenant resolves the organization a request acts on and stores it in the
+// locals and the user context, where the repositories pick it up. The tenant
+// comes from the principal unless an X-Tenant-ID header selects another one,
+// which requires the tenants:switch permission. It must run after
+// Authenticate.
+func Tenant(config TenantConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			return NewUnauthorizedError("Not authenticated")
+		}
+
+		tenantID := principal.TenantID
+
+		if header := c.Get(HeaderTenantID); header != "" {
+			id, err := strconv.ParseUint(header, 10, 32)
+			if err != nil || id == 0 {
+				return NewBadRequestError("Invalid " + HeaderTenantID + " header")
+			}
+
+			if uint(id) != principal.TenantID {
+				if !config.Policy.Can(principal, PermTenantsSwitch) {
+					return NewForbiddenError("Insufficient permissions to act on another organization", fiber.Map{
+						"required": PermTenantsSwitch,
+					})
+				}
+
+				exists, err := config.Lookup.TenantExists(c.UserContext(), uint(id))
+				if err != nil {
+					return err
+				}
+				if !exists {
+					return NewNotFoundError("Organization not found")
+				}
+			}
+
+			tenantID = uint(id)
+		}
+
+		if tenantID == 0 {
+			return NewForbiddenError("No organization selected")
+		}
+
+		SetTenant(c, tenantID)
+		return c.Next()
+	}
+}
+
+func SetTenant(c *fiber.Ctx, tenantID uint) {
+	c.Locals(tenantLocalsKey, tenantID)
+	c.SetUserContext(WithTenant(c.UserContext(), tenantID))
+}
+
+func GetTenant(c *fiber.Ctx) uint {
+	tenantID, _ := c.Locals(tenantLocalsKey).(uint)
+	return tenantID
+}
+
+// WithTenant is used by code running outside a request, such as background
+// jobs, to act on behalf of an organization.
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(uint)
+	return tenantID, ok && tenantID != 0
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("invoices-api/pkg/middleware")
+
+// Tracing starts a server span for every request, continuing the trace of a
+// W3C traceparent header, and stores it in the user context so that the
+// spans of the handlers, repositories and queries become its children. The
+// span is named after the matched route, if any, once the request has
+// completed. It must run before RequestLogger so that the access log carries
+// the trace ID.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Spans are exported after the request, when fiber has reused the
+		// memory of its strings, so the attributes are copies.
+		method := utils.CopyString(c.Method())
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracer.Start(ctx, "HTTP "+method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", utils.CopyString(c.Path())),
+				attribute.String("client.address", utils.CopyString(c.IP())),
+				attribute.String("user_agent.original", utils.CopyString(c.Get(fiber.HeaderUserAgent))),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if route := routeLabel(c, err); route != unmatchedRoute {
+			span.SetName(method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+
+		return err
+	}
+}
+
+// unmatchedRoute stands in for the route of requests that matched none.
+const unmatchedRoute = "unmatched"
+
+// routeLabel is the template of the route that served the request. fiber
+// reports the last middleware as the route of requests that matched none,
+// which the router's own 404 and 405 errors give away.
+func routeLabel(c *fiber.Ctx, err error) string {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		switch {
+		case fiberErr.Code == fiber.StatusMethodNotAllowed,
+			fiberErr.Code == fiber.StatusNotFound && strings.HasPrefix(fiberErr.Message, "Cannot "):
+			return unmatchedRoute
+		}
+	}
+	return c.Route().Path
+}
+
+// TraceID returns the ID of the trace the request belongs to, or "".
+func TraceID(c *fiber.Ctx) string {
+	spanContext := trace.SpanContextFromContext(c.UserContext())
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// headerCarrier exposes the request headers to the propagator.
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+func (h headerCarrier) Get(key string) string {
+	return utils.CopyString(h.c.Get(key))
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,91 @@
+// Package tracing configures OpenTelemetry. Spans are created through the
+// global tracer provider, so packages only need otel.Tracer and work
+// unchanged, without recording anything, while tracing is disabled.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+type Config struct {
+	Enabled     bool
+	ServiceName string
+	// Exporter is otlp or stdout.
+	Exporter string
+	// Endpoint is the URL of an OTLP/HTTP collector, e.g.
+	// http://localhost:4318. Plain http disables TLS.
+	Endpoint string
+	// SampleRatio is the share of new traces that are recorded. Requests
+	// that arrive with a sampled traceparent are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the W3C trace context propagator and, when enabled, a
+// tracer provider exporting to the configured backend. The returned function
+// flushes pending spans and is never nil.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Incoming trace IDs are propagated and logged even without tracing, so
+	// that logs still correlate with the caller's trace.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	noShutdown := func(context.Context) error { return nil }
+	if !cfg.Enabled {
+		return noShutdown, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("tracing.endpoint: %w", err)
+		}
+		return exporter, nil
+
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+}
@@ -94,10 +94,16 @@ func (v *InvoiceValidator) getErrorMsg(err validator.FieldError) string {
 	switch err.Tag() {
 	case "required":
 		return fmt.Sprintf("%s is required", err.Field())
-	case "min":
+	case "min", "gt":
 		return fmt.Sprintf("%s must be greater than %s", err.Field(), err.Param())
 	case "validStatus":
 		return fmt.Sprintf("%s must be one of: Paid, Pending, Unpaid", err.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", err.Field())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", err.Field(), err.Param())
+	case "gtefield":
+		return fmt.Sprintf("%s must not be before %s", err.Field(), err.Param())
 	default:
 		return fmt.Sprintf("%s is not valid", err.Field())
 	}